@@ -0,0 +1,184 @@
+// Copyright 2024 John Schellinger.
+// Use of this file is governed by the MIT license that can
+// be found in the LICENSE.txt file in the project root.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/jseashell/lyrics-db-seeder/internal/config"
+	"github.com/jseashell/lyrics-db-seeder/internal/genius"
+	"github.com/jseashell/lyrics-db-seeder/internal/manifest"
+	"github.com/jseashell/lyrics-db-seeder/internal/names"
+	"github.com/jseashell/lyrics-db-seeder/internal/search"
+)
+
+// Settings describing the artists to collect, shared by every command that resolves artists
+var artistSettings = []string{
+	"ARTIST",
+	"ARTIST_ALIASES",
+	"ARTIST_IDS",
+	"AFFILIATIONS",
+	"INCLUDE_FEATURED",
+	"INCLUDE_ANDED",
+	"EXCLUDE_ARTIST_IDS",
+	"EXCLUDE_ARTIST_NAMES",
+	"MATCH_MODE",
+	"MANIFEST",
+}
+
+// Settings of every command that calls Genius.com
+var geniusSettings = []string{"GENIUS_ACCESS_TOKEN", "GENIUS_RATE_LIMIT", "LOG_LEVEL"}
+
+// Combines groups of settings into a single list
+func settings(groups ...[]string) []string {
+	keys := []string{}
+	for _, group := range groups {
+		keys = append(keys, group...)
+	}
+	return keys
+}
+
+// Loads the artists to seed from the manifest named by `MANIFEST`, or a single artist
+// from the config. Album IDs without an artist seed only the given albums.
+func loadRoster(cfg config.Config) ([]manifest.Artist, error) {
+	if cfg.Manifest != "" {
+		m, err := manifest.Load(cfg.Manifest)
+		if err != nil {
+			return nil, err
+		}
+		return m.Artists, nil
+	}
+
+	a := manifest.Artist{
+		Name:               cfg.Artist,
+		ArtistIDs:          cfg.ArtistIDs,
+		Aliases:            cfg.ArtistAliases,
+		Affiliations:       cfg.Affiliations,
+		IncludeFeatured:    cfg.IncludeFeatured,
+		IncludeAnded:       cfg.IncludeAnded,
+		ExcludeArtistIDs:   cfg.ExcludeArtistIDs,
+		ExcludeArtistNames: cfg.ExcludeArtistNames,
+	}
+	if a.Name == "" && len(a.ArtistIDs) == 0 && len(cfg.AlbumIDs) > 0 {
+		return []manifest.Artist{}, nil
+	}
+	return []manifest.Artist{a}, nil
+}
+
+// Rejects artists with artist IDs that list affiliations or would discover them.
+// Affiliations are searched for, and artist IDs bypass search, so they would be unused.
+func checkAffiliations(roster []manifest.Artist, discoverCount int) error {
+	errs := []error{}
+	for _, a := range roster {
+		if len(a.ArtistIDs) == 0 {
+			continue
+		}
+		if len(a.Affiliations) > 0 {
+			errs = append(errs, fmt.Errorf("%s: affiliations cannot be combined with artist IDs, which bypass search", a.String()))
+		}
+		if discoverCount > 0 {
+			errs = append(errs, fmt.Errorf("%s: DISCOVER_AFFILIATIONS (-discover-affiliations) cannot be combined with artist IDs, which bypass search", a.String()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Builds [search.Options] for the given artist
+func searchOptions(a manifest.Artist, matchMode names.Mode) search.Options {
+	return search.Options{
+		Aliases:         a.Aliases,
+		Affiliations:    a.Affiliations,
+		IncludeFeatured: a.IncludeFeatured,
+		IncludeAnded:    a.IncludeAnded,
+		ExcludeIDs:      a.ExcludeArtistIDs,
+		ExcludeNames:    a.ExcludeArtistNames,
+		MatchMode:       matchMode,
+	}
+}
+
+// Finds artist IDs for the given artist name and affiliations via [search.Query].
+// Returns the artist name to match for each artist ID.
+func searchArtistIds(ctx context.Context, artistName string, opts search.Options) (map[int]string, error) {
+	artistIds, err := search.Query(ctx, artistName, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	artists := map[int]string{}
+	for _, id := range artistIds {
+		artists[id] = artistName
+	}
+	return artists, nil
+}
+
+// Finds the artist IDs to collect for the artist: its own artist IDs, confirmed, if set,
+// otherwise the artist IDs found by searching for its name and affiliations
+func resolveArtistIds(ctx context.Context, a manifest.Artist, matchMode names.Mode) (map[int]string, error) {
+	if len(a.ArtistIDs) > 0 {
+		return confirmArtistIds(ctx, a.Name, a.Aliases, a.ArtistIDs)
+	}
+	return searchArtistIds(ctx, a.Name, searchOptions(a, matchMode))
+}
+
+// Fetches each of the given artist IDs to confirm that it exists, bypassing search.
+// Returns the artist name to match for each artist ID, which is the given artist
+// name if set, otherwise the name on Genius.com.
+func confirmArtistIds(ctx context.Context, artistName string, aliases []string, artistIds []int) (map[int]string, error) {
+	artists := map[int]string{}
+	for _, id := range artistIds {
+		artist, err := genius.ArtistById(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("artist %d: %w", id, err)
+		}
+
+		if artistName == "" {
+			artists[id] = artist.Name
+		} else {
+			if !names.New(artistName, aliases...).Matches(artist.Name) {
+				slog.Warn("Artist name mismatch", slog.Int("artist_id", id), "name", artist.Name, "artist", artistName)
+			}
+			artists[id] = artistName
+		}
+		slog.Info("Confirmed artist", slog.Int("artist_id", id), "name", artist.Name)
+	}
+	return artists, nil
+}
+
+// Fetches every song of the artist's own artist IDs. Uses the artist's IDs if any,
+// otherwise searches for artist IDs named exactly as the artist.
+func artistSongs(ctx context.Context, a manifest.Artist, matchMode names.Mode) ([]genius.Song, error) {
+	artistIds := a.ArtistIDs
+
+	if len(artistIds) == 0 {
+		artist := names.New(a.Name, a.Aliases...)
+		ownOpts := searchOptions(a, matchMode)
+		ownOpts.Affiliations = nil
+		for _, candidate := range search.Candidates(ctx, a.Name, ownOpts) {
+			if candidate.Excluded == "" && artist.Is(candidate.Name) {
+				artistIds = append(artistIds, candidate.ID)
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if len(artistIds) == 0 {
+			return nil, fmt.Errorf("no artist IDs named %q", a.Name)
+		}
+	}
+
+	songs := []genius.Song{}
+	for _, id := range artistIds {
+		idSongs, err := genius.AllSongs(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("artist %d: %w", id, err)
+		}
+		songs = append(songs, idSongs...)
+	}
+
+	return songs, nil
+}
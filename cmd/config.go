@@ -0,0 +1,35 @@
+// Copyright 2024 John Schellinger.
+// Use of this file is governed by the MIT license that can
+// be found in the LICENSE.txt file in the project root.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/jseashell/lyrics-db-seeder/internal/config"
+)
+
+// Prints the effective config merged from flags, environment variables, the config
+// file and defaults
+func runConfig(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprintf(os.Stderr, "Usage: %s config print [flags]\n", programName)
+		return errUsage
+	}
+
+	keys := []string{}
+	for _, s := range config.Settings {
+		keys = append(keys, s.Key)
+	}
+
+	fs := newFlagSet("config print", "", "Prints the effective config as YAML, commenting each setting with where it came from:\nflag, env, file or default. The Genius access token is redacted.", keys...)
+	cfg, err := parse(fs, args[1:])
+	if err != nil {
+		return err
+	}
+
+	return cfg.WriteYAML(os.Stdout)
+}
@@ -0,0 +1,124 @@
+// Copyright 2024 John Schellinger.
+// Use of this file is governed by the MIT license that can
+// be found in the LICENSE.txt file in the project root.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/jseashell/lyrics-db-seeder/internal/config"
+	"github.com/jseashell/lyrics-db-seeder/internal/discover"
+	"github.com/jseashell/lyrics-db-seeder/internal/manifest"
+	"github.com/jseashell/lyrics-db-seeder/internal/names"
+)
+
+// Settings of the `discover` command
+var discoverSettings = settings(geniusSettings, artistSettings, []string{"DISCOVER_AFFILIATIONS"})
+
+// Prints the ranked collaborators of each artist, without seeding
+func runDiscover(ctx context.Context, args []string) error {
+	fs := newFlagSet("discover", "", "Prints the collaborators of ARTIST or the artists in MANIFEST ranked by shared songs.\nThe top DISCOVER_AFFILIATIONS (default 10) are marked.", discoverSettings...)
+	cfg, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := errors.Join(config.Require("GENIUS_ACCESS_TOKEN"), config.RequireAny("ARTIST", "ARTIST_IDS", "MANIFEST")); err != nil {
+		return err
+	}
+	setup(cfg)
+
+	matchMode, err := names.ParseMode(cfg.MatchMode)
+	if err != nil {
+		return err
+	}
+
+	roster, err := loadRoster(cfg)
+	if err != nil {
+		return err
+	}
+
+	discoverCount := cfg.DiscoverAffiliations
+	if discoverCount <= 0 {
+		discoverCount = 10
+	}
+
+	for _, a := range roster {
+		collaborators, err := discoverCollaborators(ctx, a, matchMode)
+		if err != nil {
+			return err
+		}
+		printCollaborators(os.Stdout, a.String(), collaborators, discoverCount)
+	}
+	return nil
+}
+
+// Walks every song of the artist's own artist IDs and ranks their collaborators
+func discoverCollaborators(ctx context.Context, a manifest.Artist, matchMode names.Mode) ([]discover.Collaborator, error) {
+	artist, err := artistMatcher(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+
+	songs, err := artistSongs(ctx, a, matchMode)
+	if err != nil {
+		return nil, err
+	}
+
+	return discover.Collaborators(songs, artist), nil
+}
+
+// Matches credits of the artist by its name and aliases. Artists listed only by artist
+// IDs are matched by their names on Genius.com.
+func artistMatcher(ctx context.Context, a manifest.Artist) (names.Matcher, error) {
+	if a.Name != "" {
+		return names.New(a.Name, a.Aliases...), nil
+	}
+
+	artists, err := confirmArtistIds(ctx, "", nil, a.ArtistIDs)
+	if err != nil {
+		return names.Matcher{}, err
+	}
+	geniusNames := []string{}
+	for _, id := range a.ArtistIDs {
+		geniusNames = append(geniusNames, artists[id])
+	}
+	return names.New(geniusNames[0], append(geniusNames[1:], a.Aliases...)...), nil
+}
+
+// Prints a ranked table of collaborators, marking the top n
+func printCollaborators(out io.Writer, artistName string, collaborators []discover.Collaborator, n int) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Collaborators of %q (* top %d)\n\n", artistName, n)
+	fmt.Fprintln(w, "RANK\tARTIST ID\tNAME\tSONGS\tPRIMARY\tFEATURED")
+	for i, c := range collaborators {
+		rank := strconv.Itoa(i + 1)
+		if i < n {
+			rank += "*"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%d\t%d\t%d\n", rank, c.ID, c.Name, c.Songs, c.Primary, c.Featured)
+	}
+	w.Flush()
+}
+
+// Appends discovered affiliations that are not already listed
+func mergeAffiliations(affiliations []string, discovered []string) []string {
+	seen := map[string]bool{}
+	for _, affiliation := range affiliations {
+		seen[names.Normalize(affiliation)] = true
+	}
+
+	for _, affiliation := range discovered {
+		if !seen[names.Normalize(affiliation)] {
+			seen[names.Normalize(affiliation)] = true
+			affiliations = append(affiliations, affiliation)
+		}
+	}
+	return affiliations
+}
@@ -0,0 +1,94 @@
+// Copyright 2024 John Schellinger.
+// Use of this file is governed by the MIT license that can
+// be found in the LICENSE.txt file in the project root.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/jseashell/lyrics-db-seeder/internal/album"
+	"github.com/jseashell/lyrics-db-seeder/internal/diff"
+	"github.com/jseashell/lyrics-db-seeder/internal/scraper"
+)
+
+// [db.Sink] that records how each song would change the saved songs instead of
+// saving it
+type dryRunSink struct {
+	saved []scraper.ScrapedSong
+	index diff.Index
+
+	mu     sync.Mutex
+	songs  []diff.SongDiff
+	albums []album.Album
+}
+
+func newDryRunSink(saved []scraper.ScrapedSong) *dryRunSink {
+	return &dryRunSink{saved: saved, index: diff.NewIndex(saved)}
+}
+
+func (d *dryRunSink) PutSong(ctx context.Context, song scraper.ScrapedSong) error {
+	songDiff := d.index.Diff(song)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.songs = append(d.songs, songDiff)
+	return nil
+}
+
+func (d *dryRunSink) PutAlbum(ctx context.Context, a album.Album) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.albums = append(d.albums, a)
+	return nil
+}
+
+func (d *dryRunSink) Songs(ctx context.Context) ([]scraper.ScrapedSong, error) {
+	return d.saved, nil
+}
+
+// Seeding never deletes songs
+func (d *dryRunSink) DeleteSong(ctx context.Context, song scraper.ScrapedSong) error {
+	return nil
+}
+
+// Prints the songs that would be added, duplicated or updated, with their changed fields and lyric
+// lines, followed by the albums that would be saved and the totals
+func (d *dryRunSink) print(out io.Writer) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	slices.SortFunc(d.songs, func(a, b diff.SongDiff) int {
+		return a.SongID - b.SongID
+	})
+
+	counts := map[diff.Status]int{}
+	fmt.Fprintln(out, "Dry run, nothing was saved")
+	for _, song := range d.songs {
+		counts[song.Status]++
+		switch song.Status {
+		case diff.Added:
+			fmt.Fprintf(out, "\n+ %s (%d)\n", song.Title, song.SongID)
+		case diff.Duplicate:
+			fmt.Fprintf(out, "\n+ %s (%d), duplicate of saved %s\n", song.Title, song.SongID, song.SavedID)
+		case diff.Updated:
+			fmt.Fprintf(out, "\n~ %s (%d)\n", song.Title, song.SongID)
+			for _, f := range song.Fields {
+				fmt.Fprintf(out, "    %s: %q -> %q\n", f.Name, f.Saved, f.New)
+			}
+			for _, line := range song.Lyrics {
+				fmt.Fprintf(out, "    %s %s\n", line.Op, line.Text)
+			}
+		}
+	}
+
+	for _, a := range d.albums {
+		fmt.Fprintf(out, "\n+ album %s (%d), %d tracks\n", a.Album.FullTitle, a.Album.ID, len(a.Tracks))
+	}
+
+	fmt.Fprintf(out, "\n%d new, %d duplicate, %d updated, %d unchanged songs, %d albums\n", counts[diff.Added], counts[diff.Duplicate], counts[diff.Updated], counts[diff.Unchanged], len(d.albums))
+}
@@ -0,0 +1,80 @@
+// Copyright 2024 John Schellinger.
+// Use of this file is governed by the MIT license that can
+// be found in the LICENSE.txt file in the project root.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/jseashell/lyrics-db-seeder/internal/config"
+	"github.com/jseashell/lyrics-db-seeder/internal/genius"
+	"github.com/jseashell/lyrics-db-seeder/internal/graph"
+	"github.com/jseashell/lyrics-db-seeder/internal/manifest"
+	"github.com/jseashell/lyrics-db-seeder/internal/names"
+)
+
+// Settings of the `export` command
+var exportSettings = settings(geniusSettings, artistSettings, []string{"GRAPH_FORMAT", "GRAPH_OUTPUT"})
+
+// Writes the collaboration graph of every artist, without seeding
+func runExport(ctx context.Context, args []string) error {
+	fs := newFlagSet("export", "", "Writes the collaboration graph of ARTIST or the artists in MANIFEST, weighted by shared songs.", exportSettings...)
+	cfg, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := errors.Join(config.Require("GENIUS_ACCESS_TOKEN"), config.RequireAny("ARTIST", "ARTIST_IDS", "MANIFEST")); err != nil {
+		return err
+	}
+	setup(cfg)
+
+	matchMode, err := names.ParseMode(cfg.MatchMode)
+	if err != nil {
+		return err
+	}
+
+	roster, err := loadRoster(cfg)
+	if err != nil {
+		return err
+	}
+
+	return exportGraph(ctx, roster, matchMode, cfg.GraphFormat, cfg.GraphOutput)
+}
+
+// Writes the collaboration graph of every artist's own artist IDs to the given path in
+// the given format. Defaults to "collaborations.dot".
+func exportGraph(ctx context.Context, roster []manifest.Artist, matchMode names.Mode, format string, path string) error {
+	if format == "" {
+		format = graph.DOT
+	}
+	if err := graph.CheckFormat(format); err != nil {
+		return err
+	}
+
+	songs := []genius.Song{}
+	for _, a := range roster {
+		artistSongs, err := artistSongs(ctx, a, matchMode)
+		if err != nil {
+			return err
+		}
+		songs = append(songs, artistSongs...)
+	}
+	g := graph.Build(songs)
+
+	if path == "" {
+		path = fmt.Sprintf("collaborations.%s", format)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	slog.Info("Export graph", "path", path, "format", format, "nodes", len(g.Nodes), "edges", len(g.Edges))
+	return g.Write(file, format)
+}
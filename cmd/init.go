@@ -0,0 +1,47 @@
+// Copyright 2024 John Schellinger.
+// Use of this file is governed by the MIT license that can
+// be found in the LICENSE.txt file in the project root.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jseashell/lyrics-db-seeder/internal/config"
+)
+
+// Writes an env file with every setting and its default value
+func runInit(ctx context.Context, args []string) error {
+	fs := newFlagSet("init", "", "Writes the env file (see -env-file) with every setting and its default value.")
+	force := fs.Bool("force", false, "Overwrite an existing env file")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if *force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	file, err := os.OpenFile(envFile, flags, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s already exists, use -force to overwrite", envFile)
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := config.WriteEnvFile(file); err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote %s. Set GENIUS_ACCESS_TOKEN and ARTIST, then run \"%s seed\".\n", envFile, programName)
+	return nil
+}
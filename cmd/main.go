@@ -7,134 +7,159 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log/slog"
 	"os"
-	"strconv"
+	"os/signal"
 	"strings"
-	"sync"
-	"time"
+	"syscall"
+	"text/tabwriter"
 
-	"github.com/google/uuid"
-	"github.com/joho/godotenv"
-	"github.com/jseashell/lyrics-db-seeder/internal/db"
+	"github.com/jseashell/lyrics-db-seeder/internal/config"
 	"github.com/jseashell/lyrics-db-seeder/internal/genius"
 	"github.com/jseashell/lyrics-db-seeder/internal/logger"
-	"github.com/jseashell/lyrics-db-seeder/internal/scraper"
-	"github.com/jseashell/lyrics-db-seeder/internal/search"
 )
 
+const programName = "lyrics-db-seeder"
+
+// A subcommand of the CLI
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{"seed", "Scrape and save every song of the configured artists", runSeed},
+	{"search", "Print the artist IDs search would collect and why", runSearch},
+	{"discover", "Print the artist's collaborators ranked by shared songs", runDiscover},
+	{"export", "Export the artists' collaboration graph", runExport},
+	{"scrape", "Scrape a single song and print its lyrics", runScrape},
+	{"stats", "Print statistics about the saved songs", runStats},
+	{"reconcile", "Delete or flag saved songs no longer listed on Genius.com", runReconcile},
+	{"init", "Write an env file with every setting and its default", runInit},
+	{"config", "Print the effective config", runConfig},
+}
+
+// Path of the env file, set by the global `-env-file` flag
+var envFile = ".env"
+
+// Path of the YAML or TOML config file, set by the global `-config` flag
+var configFile = ""
+
 func main() {
-	start := time.Now()
+	os.Exit(run(os.Args[1:]))
+}
 
-	err := godotenv.Load()
-	if err != nil {
-		panic(err)
+// Runs the CLI and returns the exit code
+func run(args []string) int {
+	fs := flag.NewFlagSet(programName, flag.ContinueOnError)
+	fs.StringVar(&envFile, "env-file", envFile, "Env file to load settings from. Optional unless set.")
+	fs.StringVar(&configFile, "config", configFile, "YAML or TOML config file to load settings from")
+	fs.Usage = func() { usage(fs) }
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
 	}
-	artistName := os.Getenv("ARTIST")
-	includeFeatured := getenvBool("INCLUDE_FEATURED")
-	includeAnded := getenvBool("INCLUDE_ANDED")
-	affiliations := strings.Split(os.Getenv("AFFILIATIONS"), ",")
 
-	logger := logger.New()
-	slog.SetDefault(logger)
+	envFileSet := false
+	fs.Visit(func(f *flag.Flag) {
+		envFileSet = envFileSet || f.Name == "env-file"
+	})
+	if err := config.LoadEnvFile(envFile, envFileSet); err != nil {
+		fmt.Fprintf(os.Stderr, "error: env file: %v\n", err)
+		return 1
+	}
+	if configFile != "" {
+		if err := config.LoadFile(configFile); err != nil {
+			fmt.Fprintf(os.Stderr, "error: config file: %v\n", err)
+			return 1
+		}
+	}
+	config.ApplyDefaults()
 
-	artistIds, err := search.Query(artistName, affiliations, includeFeatured, includeAnded)
-	if err != nil {
-		panic(err)
+	if fs.NArg() == 0 {
+		usage(fs)
+		return 2
 	}
 
-	var wg sync.WaitGroup
-	for _, id := range artistIds {
-		wg.Add(1)
-		go func(id int) {
-			defer wg.Done()
-			processArtistId(artistName, id, includeFeatured)
-		}(id)
+	name := fs.Arg(0)
+	for _, c := range commands {
+		if c.name != name {
+			continue
+		}
+
+		// The first interrupt cancels the command, which stops gracefully. Restoring the
+		// default signal handling lets a second interrupt quit immediately.
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		context.AfterFunc(ctx, stop)
+		err := c.run(ctx, fs.Args()[1:])
+		stop()
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		if err != nil {
+			if !errors.Is(err, errUsage) {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			}
+			return 1
+		}
+		return 0
 	}
-	wg.Wait()
 
-	t := time.Now()
-	elapsed := t.Sub(start)
-	slog.Info("Seed complete", slog.Float64("seconds", elapsed.Seconds()))
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	usage(fs)
+	return 2
 }
 
-func getenvBool(key string) bool {
-	s := os.Getenv(key)
-	v, err := strconv.ParseBool(s)
-	if err != nil {
-		return false
+func usage(fs *flag.FlagSet) {
+	out := fs.Output()
+	fmt.Fprintf(out, "Usage: %s [-env-file path] [-config path] <command> [flags]\n\nCommands:\n", programName)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %s\t%s\n", c.name, c.summary)
 	}
-	return v
+	w.Flush()
+	fmt.Fprintf(out, "\nGlobal flags:\n")
+	fs.PrintDefaults()
+	fmt.Fprintf(out, "\nFlags override environment variables and the env file, which override the config file,\nwhich overrides defaults.\nRun \"%s <command> -help\" for the flags of a command.\n", programName)
 }
 
-func processArtistId(artistName string, artistId int, includeFeatured bool) {
-	pageNumber := 0
-
-	songs := sync.Map{}
-	var wg sync.WaitGroup
-	for {
-		nextSongs, nextPage := genius.Songs(artistId, artistName, pageNumber, includeFeatured)
-		wg.Add(1)
-		go func(toProcess []genius.SongWithExtras) {
-			defer wg.Done()
-			scrapedSongs := processPage(artistName, toProcess)
-			for _, scrapedSong := range scrapedSongs {
-				songs.Store(scrapedSong.Song.ID, scrapedSong)
-			}
-		}(nextSongs)
+// Returned after a flag parse error, which the flag package has already reported
+var errUsage = errors.New("usage")
+
+// Creates the flag set of a command with a flag for each of the given settings
+func newFlagSet(name string, arguments string, summary string, keys ...string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	config.Bind(fs, keys...)
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: %s %s\n\n%s\n\nFlags:\n", programName, strings.TrimSpace(name+" [flags] "+arguments), summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
 
-		if nextPage == nil {
-			break
-		} else {
-			pageNumber = *nextPage
+// Parses the command's flags, then validates and reads the effective config
+func parse(fs *flag.FlagSet, args []string) (config.Config, error) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return config.Config{}, err
 		}
+		return config.Config{}, errUsage
 	}
-	wg.Wait()
-
-	songs.Range(func(key any, value any) bool {
-		db.PutSong(value.(scraper.ScrapedSong))
-		return true
-	})
 
+	return config.Load()
 }
 
-func processPage(artistName string, nextSongs []genius.SongWithExtras) []scraper.ScrapedSong {
-	songs := []scraper.ScrapedSong{}
-
-	var wg sync.WaitGroup
-	mu := &sync.Mutex{}
-	for _, nextSong := range nextSongs {
-		wg.Add(1)
-		go func(artistName string, nextSong genius.SongWithExtras) {
-			defer wg.Done()
-
-			lyrics := scraper.Run(artistName, nextSong)
-			if len(lyrics) > 0 {
-				var scrapedSong scraper.ScrapedSong
-
-				if nextSong.Album == nil {
-					scrapedSong = scraper.ScrapedSong{
-						ID:     uuid.NewString(), // uuid is for fetching random song from AWS DynamoDB
-						Song:   nextSong,
-						Lyrics: lyrics,
-					}
-				} else {
-					scrapedSong = scraper.ScrapedSong{
-						ID:     uuid.NewString(), // uuid is for fetching random song from AWS DynamoDB
-						Song:   nextSong,
-						Album:  *nextSong.Album,
-						Lyrics: lyrics,
-					}
-				}
-
-				mu.Lock()
-				songs = append(songs, scrapedSong)
-				mu.Unlock()
-			}
-
-		}(artistName, nextSong)
-	}
-	wg.Wait()
-
-	return songs
+// Configures logging and the Genius.com rate limit. Called once a command's settings
+// are validated.
+func setup(cfg config.Config) {
+	slog.SetDefault(logger.New())
+	genius.SetRateLimit(cfg.GeniusRateLimit)
 }
@@ -0,0 +1,268 @@
+// Copyright 2024 John Schellinger.
+// Use of this file is governed by the MIT license that can
+// be found in the LICENSE.txt file in the project root.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jseashell/lyrics-db-seeder/internal/config"
+	"github.com/jseashell/lyrics-db-seeder/internal/db"
+	"github.com/jseashell/lyrics-db-seeder/internal/diff"
+	"github.com/jseashell/lyrics-db-seeder/internal/discover"
+	"github.com/jseashell/lyrics-db-seeder/internal/genius"
+	"github.com/jseashell/lyrics-db-seeder/internal/manifest"
+	"github.com/jseashell/lyrics-db-seeder/internal/names"
+	"github.com/jseashell/lyrics-db-seeder/internal/scraper"
+)
+
+// Settings of the `reconcile` command
+var reconcileSettings = settings(geniusSettings, artistSettings, []string{"DISCOVER_AFFILIATIONS", "AWS_DYNAMODB_SONGS_TABLE_NAME"})
+
+// Deletes or flags the saved songs of each artist that are no longer listed on Genius.com
+func runReconcile(ctx context.Context, args []string) error {
+	fs := newFlagSet("reconcile", "", "Compares the songs saved in AWS_DYNAMODB_SONGS_TABLE_NAME that are credited to ARTIST or the\nartists in MANIFEST with the songs Genius.com currently lists for them, and deletes the\nsaved songs that are no longer listed, e.g. deleted, merged or re-attributed songs.", reconcileSettings...)
+	dryRun := fs.Bool("dry-run", false, "Print the songs that are no longer listed, without deleting or flagging them")
+	flagOrphans := fs.Bool("flag", false, "Set orphaned_at on the songs that are no longer listed instead of deleting them")
+	yes := fs.Bool("yes", false, "Skip the confirmation prompt")
+	cfg, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := errors.Join(
+		config.Require("GENIUS_ACCESS_TOKEN"),
+		config.RequireAny("ARTIST", "ARTIST_IDS", "MANIFEST"),
+		config.Require("AWS_DYNAMODB_SONGS_TABLE_NAME"),
+	); err != nil {
+		return err
+	}
+	setup(cfg)
+
+	matchMode, err := names.ParseMode(cfg.MatchMode)
+	if err != nil {
+		return err
+	}
+
+	roster, err := loadRoster(cfg)
+	if err != nil {
+		return err
+	}
+	if err := checkAffiliations(roster, cfg.DiscoverAffiliations); err != nil {
+		return err
+	}
+
+	sink, err := db.NewSink(ctx)
+	if err != nil {
+		return err
+	}
+
+	saved, err := sink.Songs(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot read saved songs: %w", err)
+	}
+
+	// A song credited to several artists is only orphaned if none of them list it
+	credited := []scraper.ScrapedSong{}
+	listed := map[int]bool{}
+	for _, a := range roster {
+		artistSaved, artistListed, err := reconcileArtist(ctx, a, matchMode, cfg.DiscoverAffiliations, saved)
+		if err != nil {
+			return fmt.Errorf("%s: %w", a.String(), err)
+		}
+		credited = append(credited, artistSaved...)
+		for id := range artistListed {
+			listed[id] = true
+		}
+	}
+	credited = uniqueSongs(credited)
+	orphans := diff.Orphans(credited, listed)
+
+	relisted := []scraper.ScrapedSong{}
+	if *flagOrphans {
+		for _, song := range credited {
+			if song.OrphanedAt != nil && listed[song.Song.ID] {
+				relisted = append(relisted, song)
+			}
+		}
+	}
+
+	printOrphans(os.Stdout, orphans)
+	fmt.Fprintf(os.Stdout, "\n%d of %d saved songs are no longer listed on Genius.com\n", len(orphans), len(credited))
+	if len(relisted) > 0 {
+		fmt.Fprintf(os.Stdout, "%d flagged songs are listed again\n", len(relisted))
+	}
+
+	if *dryRun {
+		fmt.Fprintln(os.Stdout, "Dry run, nothing was changed")
+		return nil
+	}
+
+	action := "Delete"
+	pending := orphans
+	if *flagOrphans {
+		action = "Flag"
+		pending = []scraper.ScrapedSong{}
+		for _, song := range orphans {
+			if song.OrphanedAt == nil {
+				pending = append(pending, song)
+			}
+		}
+	}
+	if len(pending) == 0 && len(relisted) == 0 {
+		return nil
+	}
+
+	prompt := fmt.Sprintf("%s %d songs in %s?", action, len(pending), cfg.SongsTable)
+	if len(pending) == 0 {
+		prompt = fmt.Sprintf("Unflag %d songs in %s?", len(relisted), cfg.SongsTable)
+	}
+	if !*yes && !confirm(os.Stdin, os.Stdout, prompt) {
+		fmt.Fprintln(os.Stdout, "Nothing was changed")
+		return nil
+	}
+
+	changed, err := pruneOrphans(ctx, sink, pending, relisted, *flagOrphans)
+	slog.Info("Reconcile complete", "action", strings.ToLower(action), "songs", changed, "unflagged", len(relisted))
+	return err
+}
+
+// Finds the artist's saved songs and the song IDs Genius.com lists for the artist, across
+// the same artist IDs as `seed`. Saved songs are the songs whose primary or featured
+// artist is one of those artist IDs.
+func reconcileArtist(ctx context.Context, a manifest.Artist, matchMode names.Mode, discoverCount int, saved []scraper.ScrapedSong) ([]scraper.ScrapedSong, map[int]bool, error) {
+	if discoverCount > 0 {
+		collaborators, err := discoverCollaborators(ctx, a, matchMode)
+		if err != nil {
+			return nil, nil, err
+		}
+		a.Affiliations = mergeAffiliations(a.Affiliations, discover.Top(collaborators, discoverCount))
+	}
+
+	artists, err := resolveArtistIds(ctx, a, matchMode)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(artists) == 0 {
+		return nil, nil, errors.New("no artist IDs found")
+	}
+
+	listed := map[int]bool{}
+	for id := range artists {
+		songs, err := genius.AllSongs(ctx, id)
+		if err != nil {
+			return nil, nil, fmt.Errorf("artist %d: %w", id, err)
+		}
+		if len(songs) == 0 {
+			// An empty listing would orphan every saved song of the artist ID
+			return nil, nil, fmt.Errorf("artist %d: Genius.com lists no songs", id)
+		}
+		for _, song := range songs {
+			listed[song.ID] = true
+		}
+		slog.Info("Listed songs", "artist", a.String(), slog.Int("artist_id", id), "songs", len(songs))
+	}
+
+	credited := []scraper.ScrapedSong{}
+	for _, song := range saved {
+		if credits(song, artists) {
+			credited = append(credited, song)
+		}
+	}
+	return credited, listed, nil
+}
+
+// Whether the song's primary or featured artist is one of the given artist IDs
+func credits(song scraper.ScrapedSong, artists map[int]string) bool {
+	if _, ok := artists[song.Song.PrimaryArtist.ID]; ok {
+		return true
+	}
+	for _, feature := range song.Song.FeaturedArtists {
+		if _, ok := artists[feature.ID]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Deletes or flags the given orphaned songs, and unflags the flagged songs that are listed
+// again. Stops at the first failure. Returns the number of songs deleted or flagged.
+func pruneOrphans(ctx context.Context, sink db.Sink, orphans []scraper.ScrapedSong, relisted []scraper.ScrapedSong, flagOrphans bool) (int, error) {
+	now := time.Now().UTC()
+	changed := 0
+	for _, song := range orphans {
+		if err := ctx.Err(); err != nil {
+			return changed, fmt.Errorf("reconcile interrupted after %d songs: %w", changed, err)
+		}
+
+		var err error
+		if flagOrphans {
+			song.OrphanedAt = &now
+			err = sink.PutSong(ctx, song)
+		} else {
+			err = sink.DeleteSong(ctx, song)
+		}
+		if err != nil {
+			return changed, fmt.Errorf("song %d: %w", song.Song.ID, err)
+		}
+		changed++
+	}
+
+	for _, song := range relisted {
+		if err := ctx.Err(); err != nil {
+			return changed, fmt.Errorf("reconcile interrupted after %d songs: %w", changed, err)
+		}
+		song.OrphanedAt = nil
+		if err := sink.PutSong(ctx, song); err != nil {
+			return changed, fmt.Errorf("song %d: %w", song.Song.ID, err)
+		}
+	}
+	return changed, nil
+}
+
+// Prints a table of the orphaned songs
+func printOrphans(out io.Writer, orphans []scraper.ScrapedSong) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SONG ID\tTITLE\tARTISTS\tSAVED ID\tFLAGGED")
+	for _, song := range orphans {
+		flagged := ""
+		if song.OrphanedAt != nil {
+			flagged = song.OrphanedAt.Format(time.DateOnly)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", song.Song.ID, song.Song.Title, song.Song.ArtistNames, song.ID, flagged)
+	}
+	w.Flush()
+}
+
+// Asks a yes or no question. Anything but "y" or "yes", including no answer, is no.
+func confirm(in io.Reader, out io.Writer, prompt string) bool {
+	fmt.Fprintf(out, "%s [y/N] ", prompt)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// Removes repeated songs, by saved ID, keeping the first
+func uniqueSongs(songs []scraper.ScrapedSong) []scraper.ScrapedSong {
+	seen := map[string]bool{}
+	unique := []scraper.ScrapedSong{}
+	for _, song := range songs {
+		if !seen[song.ID] {
+			seen[song.ID] = true
+			unique = append(unique, song)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,207 @@
+// Copyright 2024 John Schellinger.
+// Use of this file is governed by the MIT license that can
+// be found in the LICENSE.txt file in the project root.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jseashell/lyrics-db-seeder/internal/config"
+	"github.com/jseashell/lyrics-db-seeder/internal/db"
+	"github.com/jseashell/lyrics-db-seeder/internal/diff"
+	"github.com/jseashell/lyrics-db-seeder/internal/filter"
+	"github.com/jseashell/lyrics-db-seeder/internal/genius"
+	"github.com/jseashell/lyrics-db-seeder/internal/names"
+	"github.com/jseashell/lyrics-db-seeder/internal/scraper"
+)
+
+// Settings of the `scrape` command
+var scrapeSettings = settings(geniusSettings, []string{
+	"ARTIST",
+	"ARTIST_ALIASES",
+	"MATCH_MODE",
+	"INCLUDE_ANNOTATIONS",
+	"AWS_DYNAMODB_SONGS_TABLE_NAME",
+	"SKIP_DB",
+	"FILTER_MODE",
+	"FILTER_TITLE_PATTERNS",
+	"FILTER_REQUIRE_ALBUM",
+	"FILTER_TRANSLATION_ARTIST",
+	"FILTER_LYRICS_STATES",
+})
+
+// Output formats of the `scrape` command
+const (
+	pretty   = "pretty"
+	jsonText = "json"
+)
+
+// Scrapes a single song and prints it, optionally saving it
+func runScrape(ctx context.Context, args []string) error {
+	fs := newFlagSet("scrape", "<song-id | song-url>", "Scrapes a single Genius song as `seed` would and prints the scraped song. Lyric sections\nare attributed to ARTIST, defaulting to the song's primary artist.", scrapeSettings...)
+	format := fs.String("format", pretty, fmt.Sprintf("Output format, %q or %q", pretty, jsonText))
+	sections := fs.Bool("sections", false, "Print every lyric section and whether it was kept, instead of the scraped song")
+	write := fs.Bool("write", false, "Save the scraped song to AWS_DYNAMODB_SONGS_TABLE_NAME")
+	cfg, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	errs := []error{config.Require("GENIUS_ACCESS_TOKEN")}
+	if fs.NArg() != 1 {
+		errs = append(errs, errors.New("expected a song ID or URL"))
+	}
+	if *format != pretty && *format != jsonText {
+		errs = append(errs, fmt.Errorf("invalid -format %q, expected %q or %q", *format, pretty, jsonText))
+	}
+	if *write && !cfg.SkipDB {
+		errs = append(errs, config.Require("AWS_DYNAMODB_SONGS_TABLE_NAME"))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	setup(cfg)
+
+	songId, err := songIdArg(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	matchMode, err := names.ParseMode(cfg.MatchMode)
+	if err != nil {
+		return err
+	}
+
+	rules, err := filter.RulesFromEnv()
+	if err != nil {
+		return err
+	}
+
+	song := genius.SongById(ctx, songId)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if song.ID == 0 {
+		return fmt.Errorf("song %d not found", songId)
+	}
+
+	artistName := cfg.Artist
+	if artistName == "" {
+		artistName = song.PrimaryArtist.Name
+	}
+	artist := names.New(artistName, cfg.ArtistAliases...).WithMode(matchMode)
+
+	page := scraper.Fetch(ctx, song)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if *sections {
+		return printSections(os.Stdout, *format, song, page.Sections(artist))
+	}
+
+	s := &seeder{includeAnnotations: cfg.IncludeAnnotations}
+	lyrics, referents, status := page.Parse(artist, song)
+	scrapedSong := s.newScrapedSong(ctx, song, rules.Classify(song).Category, lyrics, referents, status)
+
+	if err := printScrapedSong(os.Stdout, *format, scrapedSong); err != nil {
+		return err
+	}
+
+	if *write {
+		sink, err := db.NewSink(ctx)
+		if err != nil {
+			return err
+		}
+		return writeScrapedSong(ctx, sink, scrapedSong)
+	}
+	return nil
+}
+
+// Saves the scraped song under the ID of its saved version, if any, so that scraping a
+// saved song again replaces it instead of saving a copy
+func writeScrapedSong(ctx context.Context, sink db.Sink, song scraper.ScrapedSong) error {
+	saved, err := sink.Songs(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot read saved songs: %w", err)
+	}
+	if savedSong, ok := diff.NewIndex(saved)[song.Song.ID]; ok {
+		song.ID = savedSong.ID
+	}
+	return sink.PutSong(ctx, song)
+}
+
+// Parses a song ID, or finds the song ID of a Genius.com song URL
+func songIdArg(ctx context.Context, arg string) (int, error) {
+	if id, err := strconv.Atoi(arg); err == nil {
+		return id, nil
+	}
+
+	if !strings.HasPrefix(arg, "https://") && !strings.HasPrefix(arg, "http://") {
+		return 0, fmt.Errorf("invalid song %q, expected a song ID or URL", arg)
+	}
+	return scraper.SongID(ctx, arg)
+}
+
+// Prints the scraped song as JSON, or its details followed by its lyrics
+func printScrapedSong(out io.Writer, format string, song scraper.ScrapedSong) error {
+	if format == jsonText {
+		return writeJSON(out, song)
+	}
+
+	fmt.Fprintf(out, "%s\n%s\n\n", song.Song.FullTitle, song.Song.URL)
+	fmt.Fprintf(out, "Song ID:      %d\n", song.Song.ID)
+	fmt.Fprintf(out, "Album:        %s\n", song.Album.Name)
+	fmt.Fprintf(out, "Category:     %s\n", song.Category)
+	fmt.Fprintf(out, "Lyric status: %s\n", song.LyricStatus)
+	fmt.Fprintf(out, "Lines:        %d\n", len(song.Lyrics))
+	fmt.Fprintf(out, "Referents:    %d\n", len(song.Referents))
+	if song.OriginalID != song.Song.ID {
+		fmt.Fprintf(out, "Original ID:  %d\n", song.OriginalID)
+	}
+
+	fmt.Fprintln(out)
+	for _, line := range song.Lyrics {
+		fmt.Fprintln(out, line)
+	}
+	return nil
+}
+
+// Prints the lyric sections as JSON, or each section header followed by its lines
+func printSections(out io.Writer, format string, song genius.SongWithExtras, sections []scraper.Section) error {
+	if format == jsonText {
+		return writeJSON(out, sections)
+	}
+
+	fmt.Fprintf(out, "%s\n%s\n", song.FullTitle, song.URL)
+	for _, section := range sections {
+		status := "kept"
+		if !section.Kept {
+			status = "skipped"
+		}
+
+		header := section.Header
+		if header == "" {
+			header = "(no header)"
+		}
+		fmt.Fprintf(out, "\n[%s] %s, %d lines\n", header, status, len(section.Lines))
+		for _, line := range section.Lines {
+			fmt.Fprintf(out, "  %s\n", line)
+		}
+	}
+	return nil
+}
+
+func writeJSON(out io.Writer, v any) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/jseashell/lyrics-db-seeder/internal/album"
+	"github.com/jseashell/lyrics-db-seeder/internal/genius"
+	"github.com/jseashell/lyrics-db-seeder/internal/scraper"
+)
+
+// [db.Sink] that keeps songs in memory by ID
+type memorySink struct {
+	songs map[string]scraper.ScrapedSong
+}
+
+func (m *memorySink) PutSong(ctx context.Context, song scraper.ScrapedSong) error {
+	m.songs[song.ID] = song
+	return nil
+}
+
+func (m *memorySink) PutAlbum(ctx context.Context, a album.Album) error {
+	return nil
+}
+
+func (m *memorySink) Songs(ctx context.Context) ([]scraper.ScrapedSong, error) {
+	songs := []scraper.ScrapedSong{}
+	for _, song := range m.songs {
+		songs = append(songs, song)
+	}
+	return songs, nil
+}
+
+func (m *memorySink) DeleteSong(ctx context.Context, song scraper.ScrapedSong) error {
+	delete(m.songs, song.ID)
+	return nil
+}
+
+func Test_writeScrapedSong_Twice(t *testing.T) {
+	sink := &memorySink{songs: map[string]scraper.ScrapedSong{}}
+	song := genius.SongWithExtras{Song: genius.Song{ID: 1, Title: "foo"}}
+
+	if err := writeScrapedSong(context.Background(), sink, scraper.ScrapedSong{ID: "first", Song: song}); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeScrapedSong(context.Background(), sink, scraper.ScrapedSong{ID: "second", Song: song, Lyrics: []string{"bar"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]scraper.ScrapedSong{"first": {ID: "first", Song: song, Lyrics: []string{"bar"}}}
+	if !reflect.DeepEqual(want, sink.songs) {
+		t.Fatalf("want %+v got %+v", want, sink.songs)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2024 John Schellinger.
+// Use of this file is governed by the MIT license that can
+// be found in the LICENSE.txt file in the project root.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jseashell/lyrics-db-seeder/internal/config"
+	"github.com/jseashell/lyrics-db-seeder/internal/genius"
+	"github.com/jseashell/lyrics-db-seeder/internal/manifest"
+	"github.com/jseashell/lyrics-db-seeder/internal/names"
+	"github.com/jseashell/lyrics-db-seeder/internal/search"
+)
+
+// Settings of the `search` command
+var searchSettings = settings(geniusSettings, artistSettings)
+
+// Prints every candidate artist search would collect, without seeding
+func runSearch(ctx context.Context, args []string) error {
+	fs := newFlagSet("search", "", "Prints every candidate artist ID search would collect for ARTIST or the artists in MANIFEST,\nwith each search result that matched it and why.", searchSettings...)
+	cfg, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := errors.Join(config.Require("GENIUS_ACCESS_TOKEN"), config.RequireAny("ARTIST", "MANIFEST")); err != nil {
+		return err
+	}
+	setup(cfg)
+
+	matchMode, err := names.ParseMode(cfg.MatchMode)
+	if err != nil {
+		return err
+	}
+
+	roster, err := loadRoster(cfg)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range roster {
+		if len(a.ArtistIDs) > 0 {
+			if err := printArtistIds(ctx, os.Stdout, a); err != nil {
+				return fmt.Errorf("%s: %w", a.String(), err)
+			}
+			continue
+		}
+
+		candidates := search.Candidates(ctx, a.Name, searchOptions(a, matchMode))
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		printCandidates(os.Stdout, a.Name, candidates)
+	}
+	return nil
+}
+
+// Prints the artist's own artist IDs with their names on Genius.com. Artists with
+// artist IDs are collected by those IDs, without searching.
+func printArtistIds(ctx context.Context, out io.Writer, a manifest.Artist) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Artist IDs of %q, not searched\n\n", a.String())
+	fmt.Fprintln(w, "ARTIST ID\tNAME")
+	for _, id := range a.ArtistIDs {
+		artist, err := genius.ArtistById(ctx, id)
+		if err != nil {
+			return fmt.Errorf("artist %d: %w", id, err)
+		}
+		fmt.Fprintf(w, "%d\t%s\n", id, artist.Name)
+	}
+	return w.Flush()
+}
+
+// Prints a table of candidate artists and why each matched
+func printCandidates(out io.Writer, artistName string, candidates []search.Candidate) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Candidates for %q\n\n", artistName)
+	fmt.Fprintln(w, "ARTIST ID\tNAME\tSTATUS\tSEARCH TERM\tARTIST NAMES\tREASON")
+	for _, candidate := range candidates {
+		status := "included"
+		if candidate.Excluded != "" {
+			status = "excluded: " + candidate.Excluded
+		}
+
+		for i, match := range candidate.Matches {
+			if i == 0 {
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", candidate.ID, candidate.Name, status, match.SearchTerm, match.ArtistNames, match.Reason)
+			} else {
+				fmt.Fprintf(w, "\t\t\t%s\t%s\t%s\n", match.SearchTerm, match.ArtistNames, match.Reason)
+			}
+		}
+	}
+	w.Flush()
+}
@@ -0,0 +1,772 @@
+// Copyright 2024 John Schellinger.
+// Use of this file is governed by the MIT license that can
+// be found in the LICENSE.txt file in the project root.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jseashell/lyrics-db-seeder/internal/album"
+	"github.com/jseashell/lyrics-db-seeder/internal/checkpoint"
+	"github.com/jseashell/lyrics-db-seeder/internal/config"
+	"github.com/jseashell/lyrics-db-seeder/internal/db"
+	"github.com/jseashell/lyrics-db-seeder/internal/diff"
+	"github.com/jseashell/lyrics-db-seeder/internal/discover"
+	"github.com/jseashell/lyrics-db-seeder/internal/filter"
+	"github.com/jseashell/lyrics-db-seeder/internal/genius"
+	"github.com/jseashell/lyrics-db-seeder/internal/manifest"
+	"github.com/jseashell/lyrics-db-seeder/internal/names"
+	"github.com/jseashell/lyrics-db-seeder/internal/pipeline"
+	"github.com/jseashell/lyrics-db-seeder/internal/report"
+	"github.com/jseashell/lyrics-db-seeder/internal/scraper"
+)
+
+// Settings of the `seed` command
+var seedSettings = settings(geniusSettings, artistSettings, []string{
+	"DISCOVER_AFFILIATIONS",
+	"INCLUDE_INCOMPLETE",
+	"INCLUDE_ANNOTATIONS",
+	"INCLUDE_ALBUMS",
+	"ALBUM_IDS",
+	"FETCH_WORKERS",
+	"SCRAPE_WORKERS",
+	"WRITE_WORKERS",
+	"QUEUE_SIZE",
+	"INCREMENTAL",
+	"SUMMARY_FILE",
+	"CHECKPOINT_FILE",
+	"AWS_DYNAMODB_SONGS_TABLE_NAME",
+	"AWS_DYNAMODB_ALBUMS_TABLE_NAME",
+	"SKIP_DB",
+	"FILTER_MODE",
+	"FILTER_TITLE_PATTERNS",
+	"FILTER_REQUIRE_ALBUM",
+	"FILTER_TRANSLATION_ARTIST",
+	"FILTER_LYRICS_STATES",
+})
+
+// Scrapes and saves every song of the configured artists, then any albums
+func runSeed(ctx context.Context, args []string) error {
+	fs := newFlagSet("seed", "", "Scrapes and saves every song of ARTIST, ARTIST_IDS or the artists in MANIFEST.\nWith only ALBUM_IDS, saves the given albums.", seedSettings...)
+	resume := fs.Bool("resume", false, "Continue the seed recorded in CHECKPOINT_FILE instead of starting over")
+	dryRun := fs.Bool("dry-run", false, "Print how the seed would change the saved songs, without saving anything")
+	cfg, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := validateSeed(cfg, *resume, *dryRun); err != nil {
+		return err
+	}
+	setup(cfg)
+
+	start := time.Now()
+
+	matchMode, err := names.ParseMode(cfg.MatchMode)
+	if err != nil {
+		return err
+	}
+
+	roster, err := loadRoster(cfg)
+	if err != nil {
+		return err
+	}
+	if err := checkAffiliations(roster, cfg.DiscoverAffiliations); err != nil {
+		return err
+	}
+
+	rules, err := filter.RulesFromEnv()
+	if err != nil {
+		return err
+	}
+
+	sink, err := db.NewSink(ctx)
+	if err != nil {
+		return err
+	}
+
+	var dry *dryRunSink
+	if *dryRun {
+		saved, err := sink.Songs(ctx)
+		if err != nil {
+			return fmt.Errorf("cannot read saved songs: %w", err)
+		}
+		dry = newDryRunSink(saved)
+		sink = dry
+	}
+
+	state, err := loadCheckpoint(cfg.CheckpointFile, roster, *resume)
+	if err != nil {
+		return err
+	}
+	for _, id := range cfg.AlbumIDs {
+		state.AddAlbum(id)
+	}
+
+	s := &seeder{
+		sink:               sink,
+		state:              state,
+		report:             report.New(),
+		dryRun:             *dryRun,
+		rules:              rules,
+		matchMode:          matchMode,
+		includeIncomplete:  cfg.IncludeIncomplete,
+		includeAnnotations: cfg.IncludeAnnotations,
+		includeAlbums:      cfg.IncludeAlbums,
+		discoverCount:      cfg.DiscoverAffiliations,
+	}
+
+	if cfg.Incremental {
+		saved, err := sink.Songs(ctx)
+		if err != nil {
+			return fmt.Errorf("cannot read saved songs: %w", err)
+		}
+		s.saved = diff.NewIndex(saved)
+		slog.Info("Comparing with saved songs", "songs", len(s.saved))
+	}
+
+	stopInterrupt := context.AfterFunc(ctx, func() {
+		slog.Warn("Interrupted, saving songs already scraped. Interrupt again to quit immediately.")
+	})
+	defer stopInterrupt()
+
+	s.start(ctx, cfg.FetchWorkers, cfg.ScrapeWorkers, cfg.WriteWorkers, cfg.QueueSize)
+	summaries := []artistSummary{}
+	for _, a := range roster {
+		if ctx.Err() != nil {
+			break
+		}
+		summaries = append(summaries, s.seedArtist(ctx, a))
+	}
+	s.stop()
+
+	if ctx.Err() == nil {
+		s.processAlbums(ctx)
+	}
+
+	t := time.Now()
+	elapsed := t.Sub(start)
+
+	printSummaries(os.Stdout, summaries, cfg.Incremental)
+	s.finishReport(summaries, elapsed, ctx.Err() != nil, cfg.SummaryFile)
+	s.finishCheckpoint(summaries, ctx.Err() != nil)
+	if dry != nil {
+		fmt.Fprintln(os.Stdout)
+		dry.print(os.Stdout)
+	}
+
+	if err := ctx.Err(); err != nil {
+		slog.Warn("Seed interrupted", slog.Float64("seconds", elapsed.Seconds()))
+		return fmt.Errorf("seed interrupted, run again with -resume to continue: %w", err)
+	}
+	slog.Info("Seed complete", slog.Float64("seconds", elapsed.Seconds()))
+	return nil
+}
+
+// Checks the settings required to seed, before any request is made
+func validateSeed(cfg config.Config, resume bool, dryRun bool) error {
+	errs := []error{
+		config.Require("GENIUS_ACCESS_TOKEN"),
+		config.RequireAny("ARTIST", "ARTIST_IDS", "MANIFEST", "ALBUM_IDS"),
+	}
+
+	if !cfg.SkipDB {
+		errs = append(errs, config.Require("AWS_DYNAMODB_SONGS_TABLE_NAME"))
+		if cfg.IncludeAlbums || len(cfg.AlbumIDs) > 0 {
+			errs = append(errs, config.Require("AWS_DYNAMODB_ALBUMS_TABLE_NAME"))
+		}
+	} else {
+		if cfg.Incremental {
+			errs = append(errs, errors.New("INCREMENTAL (-incremental) requires saved songs, unset SKIP_DB (-skip-db)"))
+		}
+		if dryRun {
+			errs = append(errs, errors.New("-dry-run compares with saved songs, unset SKIP_DB (-skip-db)"))
+		}
+	}
+	if dryRun && resume {
+		errs = append(errs, errors.New("-dry-run cannot be combined with -resume"))
+	}
+
+	workers := []struct {
+		key   string
+		count int
+	}{{"FETCH_WORKERS", cfg.FetchWorkers}, {"SCRAPE_WORKERS", cfg.ScrapeWorkers}, {"WRITE_WORKERS", cfg.WriteWorkers}}
+	for _, w := range workers {
+		if w.count < 1 {
+			errs = append(errs, fmt.Errorf("%s must be at least 1", w.key))
+		}
+	}
+
+	if _, err := filter.RulesFromEnv(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Starts a new checkpoint for the roster, or loads the checkpoint of an interrupted seed
+// of the same roster when resuming
+func loadCheckpoint(path string, roster []manifest.Artist, resume bool) (*checkpoint.State, error) {
+	artists := []string{}
+	for _, a := range roster {
+		artists = append(artists, a.String())
+	}
+
+	if !resume {
+		return checkpoint.New(path, artists), nil
+	}
+
+	state, err := checkpoint.Load(path, artists)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resume: %w", err)
+	}
+	slog.Info("Resuming seed", "checkpoint", path, "updated_at", state.UpdatedAt)
+	return state, nil
+}
+
+// Deletes the checkpoint once everything is saved, otherwise keeps it to resume from.
+// A dry run never saves its checkpoint.
+func (s *seeder) finishCheckpoint(summaries []artistSummary, interrupted bool) {
+	if s.dryRun {
+		return
+	}
+	incomplete := interrupted || len(s.state.PendingAlbums()) > 0
+	for _, summary := range summaries {
+		if summary.Failed > 0 || summary.Err != nil {
+			incomplete = true
+		}
+	}
+
+	if !incomplete {
+		if err := s.state.Remove(); err != nil {
+			slog.Warn("Failed to remove checkpoint", "checkpoint", s.state.Path(), "error", err)
+		}
+		return
+	}
+
+	s.saveCheckpoint()
+	if !interrupted {
+		slog.Warn("Seed incomplete, run again with -resume to retry", "checkpoint", s.state.Path())
+	}
+}
+
+// Prints the run's totals and writes the summary to the given JSON file
+func (s *seeder) finishReport(summaries []artistSummary, elapsed time.Duration, interrupted bool, path string) {
+	s.report.Seconds = elapsed.Seconds()
+	s.report.Interrupted = interrupted
+	s.report.DryRun = s.dryRun
+	counts := genius.Counts()
+	s.report.APICalls = counts.Requests
+	s.report.Retries = counts.Retries
+	for _, summary := range summaries {
+		s.report.Artists = append(s.report.Artists, summary.report())
+	}
+
+	fmt.Fprintln(os.Stdout)
+	s.report.WriteTable(os.Stdout)
+
+	if err := s.report.WriteFile(path); err != nil {
+		slog.Warn("Failed to write summary", "summary_file", path, "error", err)
+	}
+}
+
+// Saves the checkpoint, except in a dry run. A failed save is logged rather than
+// stopping the seed.
+func (s *seeder) saveCheckpoint() {
+	if s.dryRun {
+		return
+	}
+	if err := s.state.Save(); err != nil {
+		slog.Warn("Failed to save checkpoint", "checkpoint", s.state.Path(), "error", err)
+	}
+}
+
+// Settings and state shared by every artist in a seed run
+type seeder struct {
+	sink               db.Sink
+	state              *checkpoint.State
+	rules              filter.Rules
+	matchMode          names.Mode
+	includeIncomplete  bool
+	includeAnnotations bool
+	includeAlbums      bool
+	discoverCount      int
+	// Totals of the run
+	report *report.Summary
+	// Songs and albums are recorded by a [dryRunSink] instead of saved
+	dryRun bool
+	// Saved songs to compare with when incremental, otherwise nil
+	saved diff.Index
+	// Songs to fetch, the first stage of the pipeline started by [seeder.start]
+	songs chan<- songJob
+	// Closed once every song left the pipeline after [seeder.stop]
+	done <-chan struct{}
+}
+
+// Number of songs processed
+type songCounts struct {
+	Written int
+	Failed  int
+	// Pages of songs that could not be listed, whose songs are not counted
+	PagesFailed int
+	// Written songs that were not saved before, when incremental
+	Added int
+	// Written songs whose metadata changed since they were saved, when incremental
+	Updated int
+	// Saved songs skipped because their metadata is unchanged, when incremental
+	Unchanged int
+}
+
+func (c *songCounts) add(other songCounts) {
+	c.Written += other.Written
+	c.Failed += other.Failed
+	c.PagesFailed += other.PagesFailed
+	c.Added += other.Added
+	c.Updated += other.Updated
+	c.Unchanged += other.Unchanged
+}
+
+// Results of seeding a single artist
+type artistSummary struct {
+	Artist    string
+	ArtistIDs []int
+	songCounts
+	Elapsed time.Duration
+	Err     error
+}
+
+// Converts the summary to a row of the run's [report.Summary]
+func (a artistSummary) report() report.Artist {
+	errMsg := ""
+	if a.Err != nil {
+		errMsg = a.Err.Error()
+	}
+	return report.Artist{
+		Name:        a.Artist,
+		ArtistIDs:   a.ArtistIDs,
+		Written:     a.Written,
+		Failed:      a.Failed,
+		PagesFailed: a.PagesFailed,
+		Added:       a.Added,
+		Updated:     a.Updated,
+		Unchanged:   a.Unchanged,
+		Seconds:     a.Elapsed.Seconds(),
+		Error:       errMsg,
+	}
+}
+
+// Resolves the artist's IDs, then scrapes and saves all of their songs
+func (s *seeder) seedArtist(ctx context.Context, a manifest.Artist) artistSummary {
+	start := time.Now()
+	summary := artistSummary{Artist: a.String()}
+	slog.Info("Seeding artist", "artist", a.String())
+
+	if s.discoverCount > 0 {
+		collaborators, err := discoverCollaborators(ctx, a, s.matchMode)
+		if err != nil {
+			summary.Err = err
+			return summary
+		}
+		printCollaborators(os.Stdout, a.String(), collaborators, s.discoverCount)
+		a.Affiliations = mergeAffiliations(a.Affiliations, discover.Top(collaborators, s.discoverCount))
+		slog.Info("Discovered affiliations", "artist", a.String(), "affiliations", a.Affiliations)
+	}
+
+	artists, err := resolveArtistIds(ctx, a, s.matchMode)
+	if err != nil {
+		summary.Err = err
+		return summary
+	}
+	s.report.ArtistIDsSearched.Add(len(artists))
+
+	mu := sync.Mutex{}
+	var wg sync.WaitGroup
+	for id, name := range artists {
+		wg.Add(1)
+		go func(id int, name string) {
+			defer wg.Done()
+			artist := names.New(name, a.Aliases...).WithMode(s.matchMode)
+			counts := s.processArtistId(ctx, artist, id, a.IncludeFeatured)
+
+			mu.Lock()
+			summary.ArtistIDs = append(summary.ArtistIDs, id)
+			summary.add(counts)
+			mu.Unlock()
+		}(id, name)
+	}
+	wg.Wait()
+
+	sort.Ints(summary.ArtistIDs)
+	summary.Elapsed = time.Since(start)
+	return summary
+}
+
+// Prints a table with a row per artist, with the songs added, updated and unchanged
+// when incremental
+func printSummaries(out io.Writer, summaries []artistSummary, incremental bool) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if incremental {
+		fmt.Fprintln(w, "ARTIST\tARTIST IDS\tADDED\tUPDATED\tUNCHANGED\tFAILED\tPAGES FAILED\tSECONDS\tERROR")
+	} else {
+		fmt.Fprintln(w, "ARTIST\tARTIST IDS\tWRITTEN\tFAILED\tPAGES FAILED\tSECONDS\tERROR")
+	}
+	for _, summary := range summaries {
+		errMsg := ""
+		if summary.Err != nil {
+			errMsg = summary.Err.Error()
+		}
+		if incremental {
+			fmt.Fprintf(w, "%s\t%v\t%d\t%d\t%d\t%d\t%d\t%.1f\t%s\n", summary.Artist, summary.ArtistIDs, summary.Added, summary.Updated, summary.Unchanged, summary.Failed, summary.PagesFailed, summary.Elapsed.Seconds(), errMsg)
+		} else {
+			fmt.Fprintf(w, "%s\t%v\t%d\t%d\t%d\t%.1f\t%s\n", summary.Artist, summary.ArtistIDs, summary.Written, summary.Failed, summary.PagesFailed, summary.Elapsed.Seconds(), errMsg)
+		}
+	}
+	w.Flush()
+}
+
+// An artist ID whose songs are streamed through the pipeline
+type artistJob struct {
+	id              int
+	artist          names.Matcher
+	includeFeatured bool
+	// Pages with songs still in the pipeline
+	pages  sync.WaitGroup
+	mu     sync.Mutex
+	counts songCounts
+}
+
+func (j *artistJob) add(counts songCounts) {
+	j.mu.Lock()
+	j.counts.add(counts)
+	j.mu.Unlock()
+}
+
+// A page of an artist ID's songs. Once all of its songs left the pipeline, the page is
+// recorded in the checkpoint unless a song failed.
+type pageJob struct {
+	artist *artistJob
+	number int
+	next   *int
+	// Songs still in the pipeline
+	pending atomic.Int32
+	failed  atomic.Bool
+}
+
+// A song in the pipeline, filled in by each stage
+type songJob struct {
+	page     *pageJob
+	id       int
+	song     genius.SongWithExtras
+	change   diff.Change
+	category filter.Category
+	scraped  scraper.ScrapedSong
+}
+
+// Starts the pipeline shared by every artist in the run. Songs are fetched, scraped and
+// saved by the given number of workers each, with up to queueSize songs waiting between
+// stages. Listing pages waits while the first stage is full. Once the context is
+// canceled, songs are no longer fetched or scraped, but songs already scraped are saved.
+func (s *seeder) start(ctx context.Context, fetchWorkers int, scrapeWorkers int, writeWorkers int, queueSize int) {
+	songs := make(chan songJob, queueSize)
+	fetched := pipeline.Stage(songs, fetchWorkers, queueSize, func(job songJob, emit func(songJob)) {
+		s.fetchSong(ctx, job, emit)
+	})
+	scraped := pipeline.Stage(fetched, scrapeWorkers, queueSize, func(job songJob, emit func(songJob)) {
+		s.scrapeSong(ctx, job, emit)
+	})
+	writeCtx := context.WithoutCancel(ctx)
+	s.songs = songs
+	s.done = pipeline.Drain(scraped, writeWorkers, func(job songJob) {
+		s.writeSong(writeCtx, job)
+	})
+}
+
+// Waits for every song in the pipeline to be saved and stops its workers
+func (s *seeder) stop() {
+	close(s.songs)
+	<-s.done
+}
+
+// Lists the pages of songs for the given artist ID and queues each song that is not
+// recorded in the checkpoint, starting from the first page not done. Stops listing when
+// the context is canceled. Returns the number of songs processed once all of them left
+// the pipeline.
+func (s *seeder) processArtistId(ctx context.Context, artist names.Matcher, artistId int, includeFeatured bool) songCounts {
+	if s.state.ArtistDone(artistId) {
+		slog.Info("Skipping seeded artist ID", "artist_id", artistId)
+		return songCounts{}
+	}
+
+	pageNumber, done := s.state.NextPage(artistId)
+	if pageNumber > 0 {
+		slog.Info("Resuming artist ID", "artist_id", artistId, "page", pageNumber)
+	}
+
+	job := &artistJob{id: artistId, artist: artist, includeFeatured: includeFeatured}
+	for !done && ctx.Err() == nil {
+		page, nextPage, err := genius.SongsPage(ctx, artistId, pageNumber)
+		if ctx.Err() != nil {
+			break
+		}
+		if err != nil {
+			slog.Error("Request failed.", slog.Int("artist_id", artistId), slog.Int("page", pageNumber), "error", err)
+			s.report.PagesFailed.Inc()
+			job.add(songCounts{PagesFailed: 1})
+			break
+		}
+
+		pending := []genius.Song{}
+		for _, song := range page {
+			if !s.state.SongDone(song.ID) {
+				pending = append(pending, song)
+			}
+		}
+		s.report.PagesFetched.Inc()
+		s.report.SongsDiscovered.Add(len(page))
+		s.report.SongsResumed.Add(len(page) - len(pending))
+
+		p := &pageJob{artist: job, number: pageNumber, next: nextPage}
+		job.pages.Add(1)
+		if len(pending) == 0 {
+			s.finishPage(p)
+		} else {
+			p.pending.Store(int32(len(pending)))
+			for _, song := range pending {
+				s.songs <- songJob{page: p, id: song.ID}
+			}
+		}
+
+		if nextPage == nil {
+			break
+		} else {
+			pageNumber = *nextPage
+		}
+	}
+	job.pages.Wait()
+
+	if _, done := s.state.NextPage(artistId); done {
+		s.state.MarkArtistDone(artistId)
+		s.saveCheckpoint()
+	}
+
+	return job.counts
+}
+
+// Fetches the song's details. Passes on songs the artist contributes to that are kept
+// by the filter and, when incremental, are not saved or changed since they were saved.
+func (s *seeder) fetchSong(ctx context.Context, job songJob, emit func(songJob)) {
+	artist := job.page.artist
+	if ctx.Err() != nil {
+		s.interrupt(job)
+		return
+	}
+
+	song := genius.SongById(ctx, job.id)
+	if ctx.Err() != nil {
+		s.interrupt(job)
+		return
+	}
+	if song.ID == 0 {
+		s.report.SongsFailed.Inc()
+		s.finish(job, songCounts{Failed: 1})
+		return
+	}
+	if !genius.Contributes(song, artist.artist, artist.includeFeatured) {
+		s.filtered("not credited")
+		s.finish(job, songCounts{})
+		return
+	}
+
+	job.song = song
+	job.change = diff.Change{Status: diff.Added}
+	if s.saved != nil {
+		job.change = s.saved.Compare(song)
+		if job.change.Status == diff.Unchanged {
+			slog.Debug("Unchanged", "song", song.FullTitle)
+			s.report.SongsUnchanged.Inc()
+			s.finish(job, songCounts{Unchanged: 1})
+			return
+		}
+	}
+
+	classification, keep := s.rules.Keep(song)
+	if !keep {
+		s.filtered(string(classification.Category))
+		s.state.MarkScraped(song.ID)
+		s.finish(job, songCounts{})
+		return
+	}
+	job.category = classification.Category
+	emit(job)
+}
+
+// Scrapes the song's lyrics. Passes on songs with lyrics, or every song when including
+// incomplete songs. Changed songs keep their saved ID.
+func (s *seeder) scrapeSong(ctx context.Context, job songJob, emit func(songJob)) {
+	if ctx.Err() != nil {
+		s.interrupt(job)
+		return
+	}
+
+	lyrics, referents, status := scraper.Run(ctx, job.page.artist.artist, job.song)
+	if ctx.Err() != nil {
+		s.interrupt(job)
+		return
+	}
+	s.report.SongsScraped.Inc()
+	if len(lyrics) == 0 {
+		s.report.SongsEmpty.Inc()
+	}
+	if status != scraper.Complete {
+		s.report.LyricStatuses.Inc(string(status))
+	}
+	if len(lyrics) == 0 && !s.includeIncomplete {
+		slog.Info("Skipping song without lyrics", "song", job.song.FullTitle, "url", job.song.URL, "lyric_status", status)
+		s.state.MarkScraped(job.id)
+		s.finish(job, songCounts{})
+		return
+	}
+
+	job.scraped = s.newScrapedSong(ctx, job.song, job.category, lyrics, referents, status)
+	if ctx.Err() != nil {
+		s.interrupt(job)
+		return
+	}
+	if job.change.Saved != nil {
+		slog.Info("Updated", "song", job.song.FullTitle, "fields", job.change.Fields)
+		job.scraped.ID = job.change.Saved.ID
+	}
+	emit(job)
+}
+
+// Saves the scraped song, recording its album when including albums
+func (s *seeder) writeSong(ctx context.Context, job songJob) {
+	if err := s.sink.PutSong(ctx, job.scraped); err != nil {
+		s.report.SongsFailed.Inc()
+		s.finish(job, songCounts{Failed: 1})
+		return
+	}
+
+	s.state.MarkWritten(job.id)
+	if s.includeAlbums && job.song.Album != nil {
+		s.state.AddAlbum(job.song.Album.ID)
+	}
+
+	s.report.SongsWritten.Inc()
+	counts := songCounts{Written: 1, Added: 1}
+	if job.change.Status == diff.Updated {
+		s.report.SongsUpdated.Inc()
+		counts = songCounts{Written: 1, Updated: 1}
+	} else {
+		s.report.SongsAdded.Inc()
+	}
+	s.finish(job, counts)
+}
+
+// Records a song dropped before scraping for the given reason
+func (s *seeder) filtered(reason string) {
+	s.report.SongsFiltered.Inc()
+	s.report.FilterReasons.Inc(reason)
+}
+
+// Records a song leaving the pipeline, finishing its page after its last song
+func (s *seeder) finish(job songJob, counts songCounts) {
+	p := job.page
+	p.artist.add(counts)
+	if counts.Failed > 0 {
+		p.failed.Store(true)
+	}
+	if p.pending.Add(-1) == 0 {
+		s.finishPage(p)
+	}
+}
+
+// Records a song leaving the pipeline unprocessed because the seed was interrupted. Its
+// page is not recorded in the checkpoint, so resuming processes the song.
+func (s *seeder) interrupt(job songJob) {
+	job.page.failed.Store(true)
+	s.finish(job, songCounts{})
+}
+
+// Records the page in the checkpoint unless a song failed
+func (s *seeder) finishPage(p *pageJob) {
+	if !p.failed.Load() {
+		s.state.MarkPageDone(p.artist.id, p.number, p.next)
+	}
+	s.saveCheckpoint()
+	p.artist.pages.Done()
+}
+
+// Builds and saves an album entity with its ordered tracklist for each album ID
+func (s *seeder) processAlbums(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, albumId := range s.state.PendingAlbums() {
+		wg.Add(1)
+		go func(albumId int) {
+			defer wg.Done()
+			a, err := album.Build(ctx, albumId)
+			if err != nil {
+				slog.Warn("Failed to build album", "album_id", albumId, "error", err)
+				return
+			}
+			if err := s.sink.PutAlbum(ctx, a); err != nil {
+				return
+			}
+			s.report.AlbumsWritten.Inc()
+			s.state.MarkAlbumSaved(albumId)
+		}(albumId)
+	}
+	wg.Wait()
+	s.saveCheckpoint()
+}
+
+// Builds a scraped song from the song and its lyrics, fetching annotations when enabled
+func (s *seeder) newScrapedSong(ctx context.Context, song genius.SongWithExtras, category filter.Category, lyrics []string, referents []scraper.Referent, status scraper.LyricStatus) scraper.ScrapedSong {
+	var scrapedSong scraper.ScrapedSong
+
+	if song.Album == nil {
+		scrapedSong = scraper.ScrapedSong{
+			ID:          uuid.NewString(), // uuid is for fetching random song from AWS DynamoDB
+			Song:        song,
+			Lyrics:      lyrics,
+			Category:    category,
+			LyricStatus: status,
+			Referents:   referents,
+		}
+	} else {
+		scrapedSong = scraper.ScrapedSong{
+			ID:          uuid.NewString(), // uuid is for fetching random song from AWS DynamoDB
+			Song:        song,
+			Album:       *song.Album,
+			Lyrics:      lyrics,
+			Category:    category,
+			LyricStatus: status,
+			Referents:   referents,
+		}
+	}
+
+	scrapedSong.Relationships = song.Links()
+	scrapedSong.OriginalID = song.OriginalID()
+
+	if s.includeAnnotations && len(referents) > 0 {
+		annotations, err := genius.Referents(ctx, song.ID)
+		if err != nil {
+			slog.Warn("Failed to fetch annotations", "song", song.FullTitle, "error", err)
+		} else {
+			scrapedSong.Annotations = annotations
+		}
+	}
+
+	return scrapedSong
+}
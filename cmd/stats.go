@@ -0,0 +1,138 @@
+// Copyright 2024 John Schellinger.
+// Use of this file is governed by the MIT license that can
+// be found in the LICENSE.txt file in the project root.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/jseashell/lyrics-db-seeder/internal/config"
+	"github.com/jseashell/lyrics-db-seeder/internal/db"
+	"github.com/jseashell/lyrics-db-seeder/internal/names"
+	"github.com/jseashell/lyrics-db-seeder/internal/scraper"
+)
+
+// Settings of the `stats` command
+var statsSettings = []string{"ARTIST", "ARTIST_ALIASES", "ARTIST_IDS", "MATCH_MODE", "AWS_DYNAMODB_SONGS_TABLE_NAME", "LOG_LEVEL"}
+
+// Prints statistics about the saved songs
+func runStats(ctx context.Context, args []string) error {
+	fs := newFlagSet("stats", "", "Prints statistics about the songs saved in AWS_DYNAMODB_SONGS_TABLE_NAME,\noptionally limited to songs credited to ARTIST or ARTIST_IDS.", statsSettings...)
+	cfg, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := config.Require("AWS_DYNAMODB_SONGS_TABLE_NAME"); err != nil {
+		return err
+	}
+	setup(cfg)
+
+	matchMode, err := names.ParseMode(cfg.MatchMode)
+	if err != nil {
+		return err
+	}
+
+	sink, err := db.NewSink(ctx)
+	if err != nil {
+		return err
+	}
+
+	songs, err := sink.Songs(ctx)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Artist != "" || len(cfg.ArtistIDs) > 0 {
+		artist := names.New(cfg.Artist, cfg.ArtistAliases...).WithMode(matchMode)
+		artistIds := map[int]string{}
+		for _, id := range cfg.ArtistIDs {
+			artistIds[id] = cfg.Artist
+		}
+
+		credited := []scraper.ScrapedSong{}
+		for _, song := range songs {
+			if credits(song, artistIds) || (cfg.Artist != "" && creditsName(song, artist)) {
+				credited = append(credited, song)
+			}
+		}
+		songs = credited
+	}
+
+	printStats(os.Stdout, songs)
+	return nil
+}
+
+// Whether the song's primary or featured artist is the given artist by name
+func creditsName(song scraper.ScrapedSong, artist names.Matcher) bool {
+	if artist.Matches(song.Song.PrimaryArtist.Name) {
+		return true
+	}
+	for _, feature := range song.Song.FeaturedArtists {
+		if artist.Matches(feature.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Prints song, line and album totals followed by song counts per lyric status,
+// category and primary artist
+func printStats(out io.Writer, songs []scraper.ScrapedSong) {
+	lines, annotated := 0, 0
+	albums := map[int]bool{}
+	statuses := map[string]int{}
+	categories := map[string]int{}
+	artists := map[string]int{}
+
+	for _, song := range songs {
+		lines += len(song.Lyrics)
+		if len(song.Referents) > 0 {
+			annotated++
+		}
+		if song.Song.Album != nil {
+			albums[song.Song.Album.ID] = true
+		}
+		statuses[string(song.LyricStatus)]++
+		categories[string(song.Category)]++
+		artists[song.Song.PrimaryArtist.Name]++
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Songs\t%d\n", len(songs))
+	fmt.Fprintf(w, "Lyric lines\t%d\n", lines)
+	fmt.Fprintf(w, "Annotated songs\t%d\n", annotated)
+	fmt.Fprintf(w, "Albums\t%d\n", len(albums))
+	printCounts(w, "LYRIC STATUS", statuses)
+	printCounts(w, "CATEGORY", categories)
+	printCounts(w, "PRIMARY ARTIST", artists)
+	w.Flush()
+}
+
+// Prints a table of counts, most first
+func printCounts(w io.Writer, heading string, counts map[string]int) {
+	keys := []string{}
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	fmt.Fprintf(w, "\n%s\tSONGS\n", heading)
+	for _, key := range keys {
+		label := key
+		if label == "" {
+			label = "(none)"
+		}
+		fmt.Fprintf(w, "%s\t%d\n", label, counts[key])
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 John Schellinger.
+// Use of this file is governed by the MIT license that can
+// be found in the LICENSE.txt file in the project root.
+
+// Package `album` builds album entities with ordered tracklists from the Genius.com API.
+package album
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/jseashell/lyrics-db-seeder/internal/genius"
+)
+
+// An album with its ordered tracklist
+type Album struct {
+	// uuid is for fetching random album from AWS DynamoDB, see [RowID]
+	ID     string       `json:"id"`
+	Album  genius.Album `json:"album"`
+	Tracks []Track      `json:"tracks"`
+}
+
+// A song's position on an [Album]
+type Track struct {
+	// Disc number, starting at 1
+	Disc int `json:"disc"`
+	// Track number on the disc, 0 for unnumbered tracks
+	Number int `json:"number"`
+	// Overall position on the album, starting at 1
+	Position    int    `json:"position"`
+	SongID      int    `json:"song_id"`
+	Title       string `json:"title"`
+	ArtistNames string `json:"artist_names"`
+	URL         string `json:"url"`
+}
+
+// Fetches an album and its tracklist identified by the given album ID
+func Build(ctx context.Context, albumId int) (Album, error) {
+	geniusAlbum, err := genius.AlbumById(ctx, albumId)
+	if err != nil {
+		return Album{}, err
+	}
+
+	tracks, err := genius.AlbumTracks(ctx, albumId)
+	if err != nil {
+		return Album{}, err
+	}
+
+	album := Album{
+		ID:     RowID(albumId),
+		Album:  geniusAlbum,
+		Tracks: Order(tracks),
+	}
+	slog.Info("Album", "album", album.Album.FullTitle, "tracks", len(album.Tracks))
+	return album, nil
+}
+
+// Row ID of the album with the given Genius album ID. The ID is a name-based uuid, so
+// it is the same on every seed and saving an album again replaces its row.
+func RowID(albumId int) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(fmt.Sprintf("https://genius.com/albums/%d", albumId))).String()
+}
+
+// Assigns disc numbers and overall positions to tracks in album order. Genius.com
+// does not expose disc numbers, so a new disc starts whenever the track number
+// does not increase.
+func Order(tracks []genius.Track) []Track {
+	ordered := []Track{}
+	disc := 1
+	previous := 0
+
+	for i, track := range tracks {
+		number := 0
+		if track.Number != nil {
+			number = *track.Number
+			if number <= previous {
+				disc++
+			}
+			previous = number
+		}
+
+		ordered = append(ordered, Track{
+			Disc:        disc,
+			Number:      number,
+			Position:    i + 1,
+			SongID:      track.Song.ID,
+			Title:       track.Song.Title,
+			ArtistNames: track.Song.ArtistNames,
+			URL:         track.Song.URL,
+		})
+	}
+
+	return ordered
+}
@@ -0,0 +1,43 @@
+package album
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jseashell/lyrics-db-seeder/internal/genius"
+)
+
+func Test_Order(t *testing.T) {
+	number := func(n int) *int { return &n }
+	tracks := []genius.Track{
+		{Number: number(1), Song: genius.Song{ID: 10}},
+		{Number: number(2), Song: genius.Song{ID: 20}},
+		{Number: number(1), Song: genius.Song{ID: 30}},
+		{Number: nil, Song: genius.Song{ID: 40}},
+		{Number: number(2), Song: genius.Song{ID: 50}},
+	}
+
+	want := []Track{
+		{Disc: 1, Number: 1, Position: 1, SongID: 10},
+		{Disc: 1, Number: 2, Position: 2, SongID: 20},
+		{Disc: 2, Number: 1, Position: 3, SongID: 30},
+		{Disc: 2, Number: 0, Position: 4, SongID: 40},
+		{Disc: 2, Number: 2, Position: 5, SongID: 50},
+	}
+	got := Order(tracks)
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want %v got %v", want, got)
+	}
+}
+
+func Test_RowID(t *testing.T) {
+	want := RowID(1234)
+	got := RowID(1234)
+	if want != got {
+		t.Fatalf("want %q got %q", want, got)
+	}
+	if other := RowID(5678); other == want {
+		t.Fatalf("want a different ID for another album got %q", other)
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright 2024 John Schellinger.
+// Use of this file is governed by the MIT license that can
+// be found in the LICENSE.txt file in the project root.
+
+// Package `checkpoint` persists the progress of a seed run to a local state file so an
+// interrupted run can resume where it stopped.
+package checkpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Progress of a seed run. Safe for concurrent use.
+type State struct {
+	mu   sync.Mutex
+	path string
+
+	// Artists of the run, to refuse resuming a different run
+	Roster []string `json:"roster"`
+	// Progress of each Genius artist ID
+	Artists map[int]*Artist `json:"artists"`
+	// Song IDs that were saved
+	Written map[int]bool `json:"written"`
+	// Song IDs that were scraped but not saved, e.g. songs without lyrics
+	Scraped map[int]bool `json:"scraped"`
+	// Album IDs to save, true once saved
+	Albums    map[int]bool `json:"albums"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// Progress of a Genius artist ID
+type Artist struct {
+	// Every page of songs was processed
+	Done bool `json:"done"`
+	// Pages of songs whose songs were all saved or skipped, mapped to the following
+	// page number, 0 after the last page
+	Pages map[int]int `json:"pages"`
+}
+
+// Creates an empty state for the given roster, saved to the given path
+func New(path string, roster []string) *State {
+	return &State{
+		path:    path,
+		Roster:  roster,
+		Artists: map[int]*Artist{},
+		Written: map[int]bool{},
+		Scraped: map[int]bool{},
+		Albums:  map[int]bool{},
+	}
+}
+
+// Loads the state saved at the given path. Returns an error if the state is for a
+// different roster.
+func Load(path string, roster []string) (*State, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := New(path, nil)
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint %s: %w", path, err)
+	}
+
+	if !slices.Equal(s.Roster, roster) {
+		return nil, fmt.Errorf("checkpoint %s is for artists %q, not %q", path, s.Roster, roster)
+	}
+	return s, nil
+}
+
+// Reports whether every page of the artist ID was processed
+func (s *State) ArtistDone(artistId int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.Artists[artistId]
+	return ok && a.Done
+}
+
+// Records that every page of the artist ID was processed
+func (s *State) MarkArtistDone(artistId int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.artist(artistId).Done = true
+}
+
+// First page of the artist ID that was not processed, following completed pages from
+// page 0. Reports whether every page was processed.
+func (s *State) NextPage(artistId int) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a := s.artist(artistId)
+	page := 0
+	seen := map[int]bool{}
+	for {
+		next, ok := a.Pages[page]
+		if !ok || seen[page] {
+			return page, false
+		}
+		if next == 0 {
+			return 0, true
+		}
+		seen[page] = true
+		page = next
+	}
+}
+
+// Records that every song on the page was saved or skipped. nextPage is nil after
+// the last page.
+func (s *State) MarkPageDone(artistId int, page int, nextPage *int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := 0
+	if nextPage != nil {
+		next = *nextPage
+	}
+	s.artist(artistId).Pages[page] = next
+}
+
+// Reports whether the song was saved, or scraped and intentionally not saved
+func (s *State) SongDone(songId int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Written[songId] || s.Scraped[songId]
+}
+
+// Records that the song was scraped but will not be saved
+func (s *State) MarkScraped(songId int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Scraped[songId] = true
+}
+
+// Records that the song was saved
+func (s *State) MarkWritten(songId int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Written[songId] = true
+}
+
+// Records an album to save, unless it was already recorded
+func (s *State) AddAlbum(albumId int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.Albums[albumId]; !ok {
+		s.Albums[albumId] = false
+	}
+}
+
+// Records that the album was saved
+func (s *State) MarkAlbumSaved(albumId int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Albums[albumId] = true
+}
+
+// Album IDs that were recorded but not saved, in ascending order
+func (s *State) PendingAlbums() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending := []int{}
+	for id, saved := range s.Albums {
+		if !saved {
+			pending = append(pending, id)
+		}
+	}
+	slices.Sort(pending)
+	return pending
+}
+
+// Writes the state to its path. The previous state is replaced atomically, so a
+// crash while saving leaves it intact.
+func (s *State) Save() error {
+	s.mu.Lock()
+	s.UpdatedAt = time.Now().UTC()
+	b, err := json.MarshalIndent(s, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// Deletes the state file, e.g. once a run completes
+func (s *State) Remove() error {
+	err := os.Remove(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Path of the state file
+func (s *State) Path() string {
+	return s.path
+}
+
+func (s *State) artist(artistId int) *Artist {
+	a, ok := s.Artists[artistId]
+	if !ok {
+		a = &Artist{Pages: map[int]int{}}
+		s.Artists[artistId] = a
+	}
+	if a.Pages == nil {
+		a.Pages = map[int]int{}
+	}
+	return a
+}
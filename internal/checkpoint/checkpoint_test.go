@@ -0,0 +1,97 @@
+package checkpoint
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Next page of an artist and whether the artist is done
+type next struct {
+	page int
+	done bool
+}
+
+func nextPage(s *State, artistId int) next {
+	page, done := s.NextPage(artistId)
+	return next{page, done}
+}
+
+func Test_State_NextPage(t *testing.T) {
+	s := New("", nil)
+
+	if want, got := (next{0, false}), nextPage(s, 1); want != got {
+		t.Errorf("want %v got %v", want, got)
+	}
+
+	two, three := 2, 3
+	s.MarkPageDone(1, 0, &two)
+	s.MarkPageDone(1, 3, nil)
+	if want, got := (next{2, false}), nextPage(s, 1); want != got {
+		t.Errorf("want %v got %v", want, got)
+	}
+
+	s.MarkPageDone(1, 2, &three)
+	if want, got := (next{0, true}), nextPage(s, 1); want != got {
+		t.Errorf("want %v got %v", want, got)
+	}
+	// Another artist
+	if want, got := (next{0, false}), nextPage(s, 2); want != got {
+		t.Errorf("want %v got %v", want, got)
+	}
+}
+
+func Test_State_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	roster := []string{"Young Thug", "Gunna"}
+
+	s := New(path, roster)
+	two := 2
+	s.MarkPageDone(1, 0, &two)
+	s.MarkArtistDone(3)
+	s.MarkWritten(10)
+	s.MarkScraped(11)
+	s.AddAlbum(100)
+	s.AddAlbum(101)
+	s.MarkAlbumSaved(101)
+	s.AddAlbum(101)
+	if err := s.Save(); err != nil {
+		t.Fatalf("want nil got %v", err)
+	}
+
+	loaded, err := Load(path, roster)
+	if err != nil {
+		t.Fatalf("want nil got %v", err)
+	}
+	if want, got := (next{2, false}), nextPage(loaded, 1); want != got {
+		t.Errorf("want %v got %v", want, got)
+	}
+	if want, got := []bool{true, false}, []bool{loaded.ArtistDone(3), loaded.ArtistDone(1)}; !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v got %v", want, got)
+	}
+	if want, got := []bool{true, true, false}, []bool{loaded.SongDone(10), loaded.SongDone(11), loaded.SongDone(12)}; !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v got %v", want, got)
+	}
+	if want, got := []int{100}, loaded.PendingAlbums(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v got %v", want, got)
+	}
+
+	// Another roster
+	want := "is for artists"
+	if _, err := Load(path, []string{"Young Thug"}); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("want %q got %v", want, err)
+	}
+
+	if err := loaded.Remove(); err != nil {
+		t.Fatalf("want nil got %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("want not exist got %v", err)
+	}
+	// Removing a removed checkpoint
+	if err := loaded.Remove(); err != nil {
+		t.Errorf("want nil got %v", err)
+	}
+}
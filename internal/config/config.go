@@ -0,0 +1,260 @@
+// Copyright 2024 John Schellinger.
+// Use of this file is governed by the MIT license that can
+// be found in the LICENSE.txt file in the project root.
+
+// Package `config` describes the app's settings. Each setting is read from an
+// environment variable, which may be loaded from an env file and overridden by
+// a command line flag.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+// Type of a setting's value
+type Kind int
+
+const (
+	String Kind = iota
+	Bool
+	Int
+	Float
+	// Comma delimited strings
+	List
+	// Comma delimited integers
+	Ints
+)
+
+// A setting read from an environment variable
+type Setting struct {
+	// Environment variable
+	Key string
+	// Command line flag, derived from Key when empty
+	Flag  string
+	Usage string
+	Kind  Kind
+	// Value written by `init`
+	Default string
+	// Allowed values, any when empty
+	Choices []string
+	// Hidden from help output
+	Secret bool
+}
+
+// All settings, in the order written by `init`
+var Settings = []Setting{
+	{Key: "GENIUS_ACCESS_TOKEN", Usage: "Access token for the Genius.com API", Secret: true},
+	{Key: "GENIUS_RATE_LIMIT", Usage: "Maximum Genius.com requests per second, 0 for unlimited", Kind: Float, Default: "0"},
+	{Key: "ARTIST", Usage: "Name of the artist to collect"},
+	{Key: "ARTIST_ALIASES", Usage: "Comma delimited names the artist also performs under", Kind: List},
+	{Key: "ARTIST_IDS", Usage: "Comma delimited Genius artist IDs to collect, bypassing search", Kind: Ints},
+	{Key: "INCLUDE_FEATURED", Usage: "Scrape songs on which the artist is featured", Kind: Bool, Default: "false"},
+	{Key: "INCLUDE_ANDED", Usage: `Scrape songs credited to the artist "and" another artist`, Kind: Bool, Default: "false"},
+	{Key: "INCLUDE_INCOMPLETE", Usage: "Save songs whose lyrics are unreleased, instrumental, or missing", Kind: Bool, Default: "false"},
+	{Key: "INCLUDE_ANNOTATIONS", Usage: "Fetch Genius annotations for each annotated song", Kind: Bool, Default: "false"},
+	{Key: "AFFILIATIONS", Usage: "Comma delimited affiliated artists to search alongside the artist", Kind: List},
+	{Key: "DISCOVER_AFFILIATIONS", Usage: "Number of top collaborators to add to AFFILIATIONS, 0 to disable", Kind: Int, Default: "0"},
+	{Key: "EXCLUDE_ARTIST_IDS", Usage: "Comma delimited artist IDs never to collect", Kind: Ints},
+	{Key: "EXCLUDE_ARTIST_NAMES", Usage: "Comma delimited artist names never to collect", Kind: List},
+	{Key: "MATCH_MODE", Usage: `How ARTIST is matched against search results, only "exact" keeps "Future" from matching "Future Islands"`, Default: "substring", Choices: []string{"substring", "word", "exact"}},
+	{Key: "MANIFEST", Usage: "YAML or JSON manifest of artists to seed, replacing the single-artist settings"},
+	{Key: "GRAPH_FORMAT", Usage: "Collaboration graph export format", Default: "dot", Choices: []string{"dot", "graphml", "json"}},
+	{Key: "GRAPH_OUTPUT", Usage: "Collaboration graph export file, defaults to collaborations.<format>"},
+	{Key: "AWS_DYNAMODB_SONGS_TABLE_NAME", Flag: "songs-table", Usage: "DynamoDB table name for songs"},
+	{Key: "AWS_DYNAMODB_ALBUMS_TABLE_NAME", Flag: "albums-table", Usage: "DynamoDB table name for albums"},
+	{Key: "INCLUDE_ALBUMS", Usage: "Save the album of every saved song, with its ordered tracklist", Kind: Bool, Default: "false"},
+	{Key: "ALBUM_IDS", Usage: "Comma delimited Genius album IDs to save", Kind: Ints},
+	{Key: "FETCH_WORKERS", Usage: "Number of songs whose details are fetched concurrently while seeding", Kind: Int, Default: "8"},
+	{Key: "SCRAPE_WORKERS", Usage: "Number of songs scraped concurrently while seeding", Kind: Int, Default: "8"},
+	{Key: "WRITE_WORKERS", Usage: "Number of songs saved concurrently while seeding", Kind: Int, Default: "4"},
+	{Key: "QUEUE_SIZE", Usage: "Number of songs queued between seeding stages", Kind: Int, Default: "50"},
+	{Key: "INCREMENTAL", Usage: "Only scrape songs that are not saved or whose title, lyrics state or album changed", Kind: Bool, Default: "false"},
+	{Key: "SUMMARY_FILE", Usage: "JSON file to write the summary of each seed to", Default: "seed-summary.json"},
+	{Key: "CHECKPOINT_FILE", Usage: "State file recording seed progress, to resume an interrupted seed", Default: ".seed-checkpoint.json"},
+	{Key: "LOG_LEVEL", Usage: "Log level", Default: "INFO", Choices: []string{"DEBUG", "INFO", "WARN", "ERROR"}},
+	{Key: "SKIP_DB", Usage: "Skip database operations", Kind: Bool, Default: "false"},
+	{Key: "FILTER_MODE", Usage: "Non-song page handling", Default: "exclude", Choices: []string{"exclude", "tag"}},
+	{Key: "FILTER_TITLE_PATTERNS", Usage: "Additional title rules as semicolon delimited category=regex pairs"},
+	{Key: "FILTER_REQUIRE_ALBUM", Usage: "Treat songs without an album as non-song pages", Kind: Bool, Default: "false"},
+	{Key: "FILTER_TRANSLATION_ARTIST", Usage: "Regex matching Genius translation accounts"},
+	{Key: "FILTER_LYRICS_STATES", Usage: "Comma delimited Genius lyric states to treat as non-song pages", Kind: List},
+}
+
+// Finds the setting for the given environment variable
+func Lookup(key string) (Setting, bool) {
+	for _, s := range Settings {
+		if s.Key == key {
+			return s, true
+		}
+	}
+	return Setting{}, false
+}
+
+// Command line flag of the setting, e.g. "include-featured" for `INCLUDE_FEATURED`
+func (s Setting) FlagName() string {
+	if s.Flag != "" {
+		return s.Flag
+	}
+	return strings.ReplaceAll(strings.ToLower(s.Key), "_", "-")
+}
+
+// Usage followed by the allowed values, if any
+func (s Setting) description() string {
+	if len(s.Choices) == 0 {
+		return s.Usage
+	}
+	return fmt.Sprintf("%s, one of %s", s.Usage, strings.Join(s.Choices, ", "))
+}
+
+var errNegative = errors.New("must not be negative")
+
+// Checks that the setting's current value has the expected type and is an allowed choice
+func (s Setting) Validate() error {
+	v := os.Getenv(s.Key)
+	if v == "" {
+		return nil
+	}
+
+	var err error
+	switch s.Kind {
+	case Bool:
+		_, err = strconv.ParseBool(v)
+	case Int:
+		var n int
+		if n, err = strconv.Atoi(v); err == nil && n < 0 {
+			err = errNegative
+		}
+	case Float:
+		var n float64
+		if n, err = strconv.ParseFloat(v, 64); err == nil && n < 0 {
+			err = errNegative
+		}
+	case Ints:
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part == "" {
+				continue
+			}
+			if _, err = strconv.Atoi(part); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s (-%s) %q: %w", s.Key, s.FlagName(), v, err)
+	}
+
+	if len(s.Choices) > 0 && !slices.Contains(s.Choices, v) {
+		return fmt.Errorf("invalid %s (-%s) %q, expected one of %s", s.Key, s.FlagName(), v, strings.Join(s.Choices, ", "))
+	}
+	return nil
+}
+
+// Loads environment variables from an env file without overriding variables that are
+// already set. A missing file is ignored unless required.
+func LoadEnvFile(path string, required bool) error {
+	err := godotenv.Load(path)
+	if err != nil && !required && errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Registers a flag for each of the given settings. Setting a flag overrides the
+// environment variable, so the flag takes precedence wherever the setting is read.
+func Bind(fs *flag.FlagSet, keys ...string) {
+	for _, key := range keys {
+		s, ok := Lookup(key)
+		if !ok {
+			panic(fmt.Sprintf("unknown setting %s", key))
+		}
+
+		usage := fmt.Sprintf("%s (env `%s`)", s.description(), s.Key)
+		if s.Kind == Bool {
+			usage = fmt.Sprintf("%s (env %s)", s.description(), s.Key)
+		}
+		fs.Var(envValue{s}, s.FlagName(), usage)
+	}
+}
+
+// Validates each of the given settings
+func Validate(keys ...string) error {
+	errs := []error{}
+	for _, key := range keys {
+		if s, ok := Lookup(key); ok {
+			errs = append(errs, s.Validate())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Reports every given setting that is empty
+func Require(keys ...string) error {
+	errs := []error{}
+	for _, key := range keys {
+		if os.Getenv(key) == "" {
+			errs = append(errs, fmt.Errorf("%s is required", describe(key)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Reports when all of the given settings are empty
+func RequireAny(keys ...string) error {
+	described := []string{}
+	for _, key := range keys {
+		if os.Getenv(key) != "" {
+			return nil
+		}
+		described = append(described, describe(key))
+	}
+	return fmt.Errorf("one of %s is required", strings.Join(described, ", "))
+}
+
+// Writes an env file with every setting and its default value
+func WriteEnvFile(w io.Writer) error {
+	var b strings.Builder
+	for _, s := range Settings {
+		fmt.Fprintf(&b, "# %s\n%s=%s\n", s.description(), s.Key, s.Default)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Environment variable followed by its flag, e.g. "ARTIST (-artist)"
+func describe(key string) string {
+	if s, ok := Lookup(key); ok {
+		return fmt.Sprintf("%s (-%s)", key, s.FlagName())
+	}
+	return key
+}
+
+// [flag.Value] that reads and writes the setting's environment variable
+type envValue struct {
+	setting Setting
+}
+
+func (v envValue) String() string {
+	if v.setting.Key == "" || v.setting.Secret {
+		return ""
+	}
+	return os.Getenv(v.setting.Key)
+}
+
+func (v envValue) Set(s string) error {
+	sources[v.setting.Key] = FromFlag
+	return os.Setenv(v.setting.Key, s)
+}
+
+func (v envValue) IsBoolFlag() bool {
+	return v.setting.Kind == Bool
+}
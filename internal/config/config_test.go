@@ -0,0 +1,145 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_Bind(t *testing.T) {
+	t.Setenv("ARTIST", "Foo")
+	t.Setenv("INCLUDE_FEATURED", "false")
+	t.Setenv("ARTIST_ALIASES", "Bar")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	Bind(fs, "ARTIST", "INCLUDE_FEATURED", "ARTIST_ALIASES", "AWS_DYNAMODB_SONGS_TABLE_NAME")
+	if err := fs.Parse([]string{"-artist", "Baz", "-include-featured", "-songs-table", "songs"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"ARTIST":                        "Baz",
+		"INCLUDE_FEATURED":              "true",
+		"ARTIST_ALIASES":                "Bar",
+		"AWS_DYNAMODB_SONGS_TABLE_NAME": "songs",
+	}
+	for key, v := range want {
+		if got := os.Getenv(key); got != v {
+			t.Errorf("%s: want %q got %q", key, v, got)
+		}
+	}
+}
+
+func Test_Bind_HidesSecrets(t *testing.T) {
+	t.Setenv("GENIUS_ACCESS_TOKEN", "secret")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	Bind(fs, "GENIUS_ACCESS_TOKEN")
+
+	var b strings.Builder
+	fs.SetOutput(&b)
+	fs.PrintDefaults()
+	if strings.Contains(b.String(), "secret") {
+		t.Fatalf("want token hidden got %q", b.String())
+	}
+}
+
+func Test_Validate(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+		want  string
+	}{
+		{"ARTIST_IDS", "1, 2,", ""},
+		{"ARTIST_IDS", "1,x", "invalid ARTIST_IDS (-artist-ids)"},
+		{"INCLUDE_FEATURED", "yes", "invalid INCLUDE_FEATURED"},
+		{"GENIUS_RATE_LIMIT", "0.5", ""},
+		{"DISCOVER_AFFILIATIONS", "1.5", "invalid DISCOVER_AFFILIATIONS"},
+		{"MATCH_MODE", "word", ""},
+		{"MATCH_MODE", "fuzzy", "expected one of substring, word, exact"},
+		{"MATCH_MODE", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Setenv(tt.key, tt.value)
+
+		err := Validate(tt.key)
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("%s=%s: want nil got %v", tt.key, tt.value, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s=%s: want %q got %v", tt.key, tt.value, tt.want, err)
+		}
+	}
+}
+
+func Test_Require(t *testing.T) {
+	t.Setenv("ARTIST", "")
+	t.Setenv("ARTIST_IDS", "")
+	t.Setenv("MANIFEST", "artists.yaml")
+
+	want := "ARTIST (-artist) is required"
+	if err := Require("ARTIST", "MANIFEST"); err == nil || err.Error() != want {
+		t.Errorf("want %q got %v", want, err)
+	}
+
+	if err := RequireAny("ARTIST", "MANIFEST"); err != nil {
+		t.Errorf("want nil got %v", err)
+	}
+
+	want = "one of ARTIST (-artist), ARTIST_IDS (-artist-ids) is required"
+	if err := RequireAny("ARTIST", "ARTIST_IDS"); err == nil || err.Error() != want {
+		t.Errorf("want %q got %v", want, err)
+	}
+}
+
+func Test_LoadEnvFile(t *testing.T) {
+	t.Setenv("ARTIST", "Foo")
+	t.Setenv("ARTIST_ALIASES", "")
+	os.Unsetenv("ARTIST_ALIASES")
+
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("ARTIST=Bar\nARTIST_ALIASES=Baz\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LoadEnvFile(path, true); err != nil {
+		t.Fatal(err)
+	}
+	// The environment takes precedence over the file
+	if want, got := "Foo", os.Getenv("ARTIST"); want != got {
+		t.Errorf("want %q got %q", want, got)
+	}
+	if want, got := "Baz", os.Getenv("ARTIST_ALIASES"); want != got {
+		t.Errorf("want %q got %q", want, got)
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing.env")
+	if err := LoadEnvFile(missing, false); err != nil {
+		t.Errorf("optional file: want nil got %v", err)
+	}
+	if err := LoadEnvFile(missing, true); err == nil {
+		t.Error("required file: want error got nil")
+	}
+}
+
+func Test_WriteEnvFile(t *testing.T) {
+	var b strings.Builder
+	if err := WriteEnvFile(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"# How ARTIST is matched against search results, only \"exact\" keeps \"Future\" from matching \"Future Islands\", one of substring, word, exact\nMATCH_MODE=substring\n",
+		"\nGENIUS_ACCESS_TOKEN=\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("want %q got %q", want, b.String())
+		}
+	}
+}
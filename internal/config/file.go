@@ -0,0 +1,214 @@
+// Copyright 2024 John Schellinger.
+// Use of this file is governed by the MIT license that can
+// be found in the LICENSE.txt file in the project root.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Where a setting's value came from, in order of precedence
+type Source string
+
+const (
+	FromFlag    Source = "flag"
+	FromEnv     Source = "env"
+	FromFile    Source = "file"
+	FromDefault Source = "default"
+)
+
+// Sources of settings set by [LoadFile], [ApplyDefaults] or a flag. Settings that are
+// set but not recorded come from the environment.
+var sources = map[string]Source{}
+
+// Where the setting's current value came from, or "" when it is unset
+func SourceOf(key string) Source {
+	if os.Getenv(key) == "" {
+		return ""
+	}
+	if source, ok := sources[key]; ok {
+		return source
+	}
+	return FromEnv
+}
+
+// Key of the setting in a config file, e.g. "include_featured" for `INCLUDE_FEATURED`
+func (s Setting) FileKey() string {
+	return strings.ToLower(s.Key)
+}
+
+// Loads settings from a YAML or TOML config file without overriding settings that are
+// already set. Files ending in ".toml" are parsed as TOML, all others as YAML. Every
+// unknown key and mistyped value is reported.
+func LoadFile(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	values := map[string]any{}
+	if filepath.Ext(path) == ".toml" {
+		err = toml.Unmarshal(b, &values)
+	} else {
+		err = yaml.Unmarshal(b, &values)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	errs := []error{}
+	applied := []string{}
+	for _, fileKey := range sortedKeys(values) {
+		s, ok := lookupFileKey(fileKey)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: unknown setting %q", path, fileKey))
+			continue
+		}
+
+		v, err := fileValue(s, values[fileKey])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s: %w", path, fileKey, err))
+			continue
+		}
+
+		if os.Getenv(s.Key) == "" && v != "" {
+			os.Setenv(s.Key, v)
+			sources[s.Key] = FromFile
+			applied = append(applied, s.Key)
+		}
+	}
+
+	if err := Validate(applied...); err != nil {
+		errs = append(errs, fmt.Errorf("%s: %w", path, err))
+	}
+	return errors.Join(errs...)
+}
+
+// Sets every unset setting to its default value
+func ApplyDefaults() {
+	for _, s := range Settings {
+		if os.Getenv(s.Key) == "" && s.Default != "" {
+			os.Setenv(s.Key, s.Default)
+			sources[s.Key] = FromDefault
+		}
+	}
+}
+
+func lookupFileKey(fileKey string) (Setting, bool) {
+	for _, s := range Settings {
+		if s.FileKey() == fileKey {
+			return s, true
+		}
+	}
+	return Setting{}, false
+}
+
+// Converts a decoded config file value into the setting's environment variable format
+func fileValue(s Setting, v any) (string, error) {
+	if v == nil {
+		return "", nil
+	}
+
+	switch s.Kind {
+	case Bool:
+		if b, ok := v.(bool); ok {
+			return strconv.FormatBool(b), nil
+		}
+		return "", fmt.Errorf("expected true or false, got %v", describeValue(v))
+	case Int:
+		if i, ok := fileInt(v); ok {
+			return strconv.Itoa(i), nil
+		}
+		return "", fmt.Errorf("expected an integer, got %v", describeValue(v))
+	case Float:
+		switch n := v.(type) {
+		case float64:
+			return strconv.FormatFloat(n, 'f', -1, 64), nil
+		default:
+			if i, ok := fileInt(v); ok {
+				return strconv.Itoa(i), nil
+			}
+		}
+		return "", fmt.Errorf("expected a number, got %v", describeValue(v))
+	case List, Ints:
+		items, ok := v.([]any)
+		if !ok {
+			if s.Kind == List {
+				return "", fmt.Errorf("expected a list of strings, got %v", describeValue(v))
+			}
+			return "", fmt.Errorf("expected a list of integers, got %v", describeValue(v))
+		}
+
+		parts := []string{}
+		for i, item := range items {
+			if s.Kind == Ints {
+				n, ok := fileInt(item)
+				if !ok {
+					return "", fmt.Errorf("[%d]: expected an integer, got %v", i, describeValue(item))
+				}
+				parts = append(parts, strconv.Itoa(n))
+				continue
+			}
+
+			str, ok := item.(string)
+			if !ok {
+				return "", fmt.Errorf("[%d]: expected a string, got %v", i, describeValue(item))
+			}
+			if strings.Contains(str, ",") {
+				return "", fmt.Errorf("[%d]: %q must not contain a comma", i, str)
+			}
+			parts = append(parts, str)
+		}
+		return strings.Join(parts, ","), nil
+	default:
+		if str, ok := v.(string); ok {
+			return str, nil
+		}
+		return "", fmt.Errorf("expected a string, got %v", describeValue(v))
+	}
+}
+
+// YAML decodes integers as int, TOML as int64
+func fileInt(v any) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	}
+	return 0, false
+}
+
+func describeValue(v any) string {
+	switch v.(type) {
+	case string:
+		return fmt.Sprintf("string %q", v)
+	case nil:
+		return "nothing"
+	case []any:
+		return "a list"
+	case map[string]any:
+		return "a table"
+	default:
+		return fmt.Sprintf("%T %v", v, v)
+	}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Unsets every setting for the duration of the test
+func clearSettings(t *testing.T) {
+	for _, s := range Settings {
+		t.Setenv(s.Key, "")
+	}
+	sources = map[string]Source{}
+}
+
+func writeFile(t *testing.T, name string, contents string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func Test_LoadFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+	}{
+		{"config.yaml", `
+artist: Young Thug
+artist_aliases: [Thugger, SEX]
+artist_ids: [1, 2]
+include_featured: true
+genius_rate_limit: 0.5
+discover_affiliations: 3
+match_mode: exact
+`},
+		{"config.toml", `
+artist = "Young Thug"
+artist_aliases = ["Thugger", "SEX"]
+artist_ids = [1, 2]
+include_featured = true
+genius_rate_limit = 0.5
+discover_affiliations = 3
+match_mode = "exact"
+`},
+	}
+
+	for _, tt := range tests {
+		clearSettings(t)
+		t.Setenv("MATCH_MODE", "word")
+
+		if err := LoadFile(writeFile(t, tt.name, tt.contents)); err != nil {
+			t.Fatalf("%s: want nil got %v", tt.name, err)
+		}
+		ApplyDefaults()
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("%s: want nil got %v", tt.name, err)
+		}
+
+		if want, got := "Young Thug", cfg.Artist; want != got {
+			t.Errorf("%s: want %q got %q", tt.name, want, got)
+		}
+		if want, got := []string{"Thugger", "SEX"}, cfg.ArtistAliases; !reflect.DeepEqual(want, got) {
+			t.Errorf("%s: want %v got %v", tt.name, want, got)
+		}
+		if want, got := []int{1, 2}, cfg.ArtistIDs; !reflect.DeepEqual(want, got) {
+			t.Errorf("%s: want %v got %v", tt.name, want, got)
+		}
+		if !cfg.IncludeFeatured {
+			t.Errorf("%s: want include featured got %v", tt.name, cfg.IncludeFeatured)
+		}
+		if cfg.GeniusRateLimit != 0.5 || cfg.DiscoverAffiliations != 3 {
+			t.Errorf("%s: want 0.5 and 3 got %v and %v", tt.name, cfg.GeniusRateLimit, cfg.DiscoverAffiliations)
+		}
+
+		// Environment takes precedence over the file, which takes precedence over defaults
+		if want, got := "word", cfg.MatchMode; want != got {
+			t.Errorf("%s: want %q got %q", tt.name, want, got)
+		}
+		if want, got := FromEnv, SourceOf("MATCH_MODE"); want != got {
+			t.Errorf("%s: want %q got %q", tt.name, want, got)
+		}
+		if want, got := FromFile, SourceOf("ARTIST"); want != got {
+			t.Errorf("%s: want %q got %q", tt.name, want, got)
+		}
+		if want, got := "exclude", cfg.FilterMode; want != got {
+			t.Errorf("%s: want %q got %q", tt.name, want, got)
+		}
+		if want, got := FromDefault, SourceOf("FILTER_MODE"); want != got {
+			t.Errorf("%s: want %q got %q", tt.name, want, got)
+		}
+	}
+}
+
+func Test_LoadFile_Errors(t *testing.T) {
+	clearSettings(t)
+
+	path := writeFile(t, "config.yaml", `
+artst: Young Thug
+artist_ids: [1, "two"]
+include_featured: "yes"
+artist_aliases: Thugger
+discover_affiliations: -1
+match_mode: fuzzy
+`)
+
+	err := LoadFile(path)
+	if err == nil {
+		t.Fatal("want error got nil")
+	}
+
+	for _, want := range []string{
+		`unknown setting "artst"`,
+		`artist_ids: [1]: expected an integer, got string "two"`,
+		`include_featured: expected true or false, got string "yes"`,
+		`artist_aliases: expected a list of strings, got string "Thugger"`,
+		`invalid DISCOVER_AFFILIATIONS (-discover-affiliations) "-1": must not be negative`,
+		`invalid MATCH_MODE (-match-mode) "fuzzy", expected one of substring, word, exact`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("want %q got %q", want, err.Error())
+		}
+	}
+}
+
+func Test_Config_CoversSettings(t *testing.T) {
+	tagged := map[string]bool{}
+	typ := reflect.TypeOf(Config{})
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		key := field.Tag.Get("env")
+		s, ok := Lookup(key)
+		if !ok {
+			t.Errorf("%s: want a setting got unknown %q", field.Name, key)
+			continue
+		}
+		if want, got := s.FileKey(), field.Tag.Get("yaml"); want != got {
+			t.Errorf("%s: want %q got %q", field.Name, want, got)
+		}
+		tagged[key] = true
+	}
+
+	for _, s := range Settings {
+		if !tagged[s.Key] {
+			t.Errorf("%s: want a Config field got none", s.Key)
+		}
+	}
+}
+
+func Test_Config_WriteYAML(t *testing.T) {
+	clearSettings(t)
+	t.Setenv("GENIUS_ACCESS_TOKEN", "secret")
+	t.Setenv("ARTIST", "Foo")
+	ApplyDefaults()
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	if err := cfg.WriteYAML(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"genius_access_token: <redacted> # env\n",
+		"artist: Foo # env\n",
+		"match_mode: substring # default\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("want %q got %q", want, b.String())
+		}
+	}
+	if strings.Contains(b.String(), "secret") {
+		t.Errorf("want token redacted got %q", b.String())
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2024 John Schellinger.
+// Use of this file is governed by the MIT license that can
+// be found in the LICENSE.txt file in the project root.
+
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Effective settings of a run. Each field is tagged with its setting's environment variable.
+type Config struct {
+	GeniusAccessToken       string   `env:"GENIUS_ACCESS_TOKEN" yaml:"genius_access_token"`
+	GeniusRateLimit         float64  `env:"GENIUS_RATE_LIMIT" yaml:"genius_rate_limit"`
+	Artist                  string   `env:"ARTIST" yaml:"artist"`
+	ArtistAliases           []string `env:"ARTIST_ALIASES" yaml:"artist_aliases"`
+	ArtistIDs               []int    `env:"ARTIST_IDS" yaml:"artist_ids"`
+	IncludeFeatured         bool     `env:"INCLUDE_FEATURED" yaml:"include_featured"`
+	IncludeAnded            bool     `env:"INCLUDE_ANDED" yaml:"include_anded"`
+	IncludeIncomplete       bool     `env:"INCLUDE_INCOMPLETE" yaml:"include_incomplete"`
+	IncludeAnnotations      bool     `env:"INCLUDE_ANNOTATIONS" yaml:"include_annotations"`
+	Affiliations            []string `env:"AFFILIATIONS" yaml:"affiliations"`
+	DiscoverAffiliations    int      `env:"DISCOVER_AFFILIATIONS" yaml:"discover_affiliations"`
+	ExcludeArtistIDs        []int    `env:"EXCLUDE_ARTIST_IDS" yaml:"exclude_artist_ids"`
+	ExcludeArtistNames      []string `env:"EXCLUDE_ARTIST_NAMES" yaml:"exclude_artist_names"`
+	MatchMode               string   `env:"MATCH_MODE" yaml:"match_mode"`
+	Manifest                string   `env:"MANIFEST" yaml:"manifest"`
+	GraphFormat             string   `env:"GRAPH_FORMAT" yaml:"graph_format"`
+	GraphOutput             string   `env:"GRAPH_OUTPUT" yaml:"graph_output"`
+	SongsTable              string   `env:"AWS_DYNAMODB_SONGS_TABLE_NAME" yaml:"aws_dynamodb_songs_table_name"`
+	AlbumsTable             string   `env:"AWS_DYNAMODB_ALBUMS_TABLE_NAME" yaml:"aws_dynamodb_albums_table_name"`
+	IncludeAlbums           bool     `env:"INCLUDE_ALBUMS" yaml:"include_albums"`
+	AlbumIDs                []int    `env:"ALBUM_IDS" yaml:"album_ids"`
+	FetchWorkers            int      `env:"FETCH_WORKERS" yaml:"fetch_workers"`
+	ScrapeWorkers           int      `env:"SCRAPE_WORKERS" yaml:"scrape_workers"`
+	WriteWorkers            int      `env:"WRITE_WORKERS" yaml:"write_workers"`
+	QueueSize               int      `env:"QUEUE_SIZE" yaml:"queue_size"`
+	Incremental             bool     `env:"INCREMENTAL" yaml:"incremental"`
+	SummaryFile             string   `env:"SUMMARY_FILE" yaml:"summary_file"`
+	CheckpointFile          string   `env:"CHECKPOINT_FILE" yaml:"checkpoint_file"`
+	LogLevel                string   `env:"LOG_LEVEL" yaml:"log_level"`
+	SkipDB                  bool     `env:"SKIP_DB" yaml:"skip_db"`
+	FilterMode              string   `env:"FILTER_MODE" yaml:"filter_mode"`
+	FilterTitlePatterns     string   `env:"FILTER_TITLE_PATTERNS" yaml:"filter_title_patterns"`
+	FilterRequireAlbum      bool     `env:"FILTER_REQUIRE_ALBUM" yaml:"filter_require_album"`
+	FilterTranslationArtist string   `env:"FILTER_TRANSLATION_ARTIST" yaml:"filter_translation_artist"`
+	FilterLyricsStates      []string `env:"FILTER_LYRICS_STATES" yaml:"filter_lyrics_states"`
+}
+
+// Validates every setting and reads them into a [Config]
+func Load() (Config, error) {
+	var c Config
+
+	keys := []string{}
+	for _, s := range Settings {
+		keys = append(keys, s.Key)
+	}
+	if err := Validate(keys...); err != nil {
+		return c, err
+	}
+
+	v := reflect.ValueOf(&c).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		key := v.Type().Field(i).Tag.Get("env")
+		if err := setField(v.Field(i), os.Getenv(key)); err != nil {
+			return c, fmt.Errorf("invalid %s: %w", key, err)
+		}
+	}
+	return c, nil
+}
+
+// Parses an environment variable value into a [Config] field
+func setField(field reflect.Value, s string) error {
+	switch field.Interface().(type) {
+	case string:
+		field.SetString(s)
+	case bool:
+		if s != "" {
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return err
+			}
+			field.SetBool(b)
+		}
+	case int:
+		if s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return err
+			}
+			field.SetInt(int64(n))
+		}
+	case float64:
+		if s != "" {
+			n, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return err
+			}
+			field.SetFloat(n)
+		}
+	case []string:
+		list := []string{}
+		for _, part := range strings.Split(s, ",") {
+			if part != "" {
+				list = append(list, part)
+			}
+		}
+		field.Set(reflect.ValueOf(list))
+	case []int:
+		ints := []int{}
+		for _, part := range strings.Split(s, ",") {
+			if part = strings.TrimSpace(part); part == "" {
+				continue
+			}
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return err
+			}
+			ints = append(ints, n)
+		}
+		field.Set(reflect.ValueOf(ints))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// Copy of the config with secrets replaced
+func (c Config) Redacted() Config {
+	if c.GeniusAccessToken != "" {
+		c.GeniusAccessToken = "<redacted>"
+	}
+	return c
+}
+
+// Writes the config as a YAML config file, commenting each setting with its [Source].
+// Secrets are redacted.
+func (c Config) WriteYAML(w io.Writer) error {
+	var doc yaml.Node
+	if err := doc.Encode(c.Redacted()); err != nil {
+		return err
+	}
+
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		key := doc.Content[i]
+		if s, ok := lookupFileKey(key.Value); ok {
+			if source := SourceOf(s.Key); source != "" {
+				// Comments on lists are only written beside the key
+				key.LineComment = string(source)
+			}
+		}
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
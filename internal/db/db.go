@@ -17,37 +17,144 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/jseashell/lyrics-db-seeder/internal/album"
 	"github.com/jseashell/lyrics-db-seeder/internal/scraper"
 )
 
-func PutSong(song scraper.ScrapedSong) {
-	dbClient := newClient()
+// Destination for seeded songs and albums. A single sink is shared by every artist in a run.
+type Sink interface {
+	// Saves a scraped song
+	PutSong(ctx context.Context, song scraper.ScrapedSong) error
+	// Saves an album entity with its ordered tracklist
+	PutAlbum(ctx context.Context, a album.Album) error
+	// Reads every saved song
+	Songs(ctx context.Context) ([]scraper.ScrapedSong, error)
+	// Deletes a saved song by its [scraper.ScrapedSong.ID]
+	DeleteSong(ctx context.Context, song scraper.ScrapedSong) error
+}
+
+// Creates the configured [Sink]. Database operations are skipped when `SKIP_DB` is true,
+// otherwise songs and albums are saved to the DynamoDB tables named by
+// `AWS_DYNAMODB_SONGS_TABLE_NAME` and `AWS_DYNAMODB_ALBUMS_TABLE_NAME`.
+func NewSink(ctx context.Context) (Sink, error) {
 	skipDb, _ := strconv.ParseBool(os.Getenv("SKIP_DB"))
-	songsTableName := os.Getenv("AWS_DYNAMODB_SONGS_TABLE_NAME")
+	if skipDb {
+		return skipSink{}, nil
+	}
+
+	client, err := newClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dynamoSink{
+		client:      client,
+		songsTable:  os.Getenv("AWS_DYNAMODB_SONGS_TABLE_NAME"),
+		albumsTable: os.Getenv("AWS_DYNAMODB_ALBUMS_TABLE_NAME"),
+	}, nil
+}
+
+// [Sink] backed by DynamoDB tables
+type dynamoSink struct {
+	client      *dynamodb.Client
+	songsTable  string
+	albumsTable string
+}
 
-	av, _ := attributevalue.MarshalMap(song)
+func (s *dynamoSink) PutSong(ctx context.Context, song scraper.ScrapedSong) error {
+	return s.put(ctx, s.songsTable, song, "song", song)
+}
+
+func (s *dynamoSink) PutAlbum(ctx context.Context, a album.Album) error {
+	return s.put(ctx, s.albumsTable, a, "album", a.Album.FullTitle)
+}
 
-	if !skipDb {
-		_, err := dbClient.PutItem(context.TODO(), &dynamodb.PutItemInput{
-			Item:      av,
-			TableName: aws.String(songsTableName),
-		})
+func (s *dynamoSink) Songs(ctx context.Context) ([]scraper.ScrapedSong, error) {
+	songs := []scraper.ScrapedSong{}
 
+	p := dynamodb.NewScanPaginator(s.client, &dynamodb.ScanInput{
+		TableName: aws.String(s.songsTable),
+	})
+	for p.HasMorePages() {
+		out, err := p.NextPage(ctx)
 		if err != nil {
-			if t := new(types.ConditionalCheckFailedException); !errors.As(err, &t) {
-				slog.Warn("Insert failed", "song", song, "error", err)
-			}
-		} else {
-			slog.Info("Insert success", "song", song)
+			return nil, err
+		}
+
+		page := []scraper.ScrapedSong{}
+		if err := attributevalue.UnmarshalListOfMaps(out.Items, &page); err != nil {
+			return nil, err
 		}
+		songs = append(songs, page...)
 	}
+
+	slog.Debug("Scan success", "table", s.songsTable, "songs", len(songs))
+	return songs, nil
+}
+
+func (s *dynamoSink) DeleteSong(ctx context.Context, song scraper.ScrapedSong) error {
+	_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		Key:       map[string]types.AttributeValue{"ID": &types.AttributeValueMemberS{Value: song.ID}},
+		TableName: aws.String(s.songsTable),
+	})
+
+	if err != nil {
+		slog.Warn("Delete failed", "song", song.Song.FullTitle, "error", err)
+		return err
+	}
+	slog.Info("Delete success", "song", song.Song.FullTitle)
+	return nil
+}
+
+func (s *dynamoSink) put(ctx context.Context, tableName string, item any, logKey string, logValue any) error {
+	av, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		Item:      av,
+		TableName: aws.String(tableName),
+	})
+
+	if err != nil {
+		if t := new(types.ConditionalCheckFailedException); !errors.As(err, &t) {
+			slog.Warn("Insert failed", logKey, logValue, "error", err)
+			return err
+		}
+	} else {
+		slog.Info("Insert success", logKey, logValue)
+	}
+	return nil
+}
+
+// [Sink] that skips database operations
+type skipSink struct{}
+
+func (skipSink) PutSong(ctx context.Context, song scraper.ScrapedSong) error {
+	slog.Debug("Insert skipped", "song", song.Song.FullTitle)
+	return nil
+}
+
+func (skipSink) PutAlbum(ctx context.Context, a album.Album) error {
+	slog.Debug("Insert skipped", "album", a.Album.FullTitle)
+	return nil
+}
+
+func (skipSink) Songs(ctx context.Context) ([]scraper.ScrapedSong, error) {
+	return nil, errors.New("no songs are saved when SKIP_DB is set")
+}
+
+func (skipSink) DeleteSong(ctx context.Context, song scraper.ScrapedSong) error {
+	slog.Debug("Delete skipped", "song", song.Song.FullTitle)
+	return nil
 }
 
-func newClient() *dynamodb.Client {
-	cfg, err := config.LoadDefaultConfig(context.TODO())
+func newClient(ctx context.Context) (*dynamodb.Client, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
 	if err != nil {
 		slog.Error("Unable to load AWS SDK config.", "error", err)
-		panic(err)
+		return nil, err
 	}
-	return dynamodb.NewFromConfig(cfg)
+	return dynamodb.NewFromConfig(cfg), nil
 }
@@ -0,0 +1,219 @@
+// Copyright 2024 John Schellinger.
+// Use of this file is governed by the MIT license that can
+// be found in the LICENSE.txt file in the project root.
+
+// Package `diff` compares Genius songs and scraped songs with the songs already saved
+// by a previous seed.
+package diff
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jseashell/lyrics-db-seeder/internal/genius"
+	"github.com/jseashell/lyrics-db-seeder/internal/scraper"
+)
+
+// How a song differs from its saved version
+type Status string
+
+const (
+	// The song is not saved
+	Added Status = "added"
+	// The song's metadata changed since it was saved
+	Updated Status = "updated"
+	// The song's metadata is unchanged since it was saved
+	Unchanged Status = "unchanged"
+	// The song is saved under another ID, so saving it adds a second copy
+	Duplicate Status = "duplicate"
+)
+
+// Metadata fields compared by [Index.Compare]
+const (
+	Title       = "title"
+	LyricsState = "lyrics_state"
+	Album       = "album"
+)
+
+// Saved songs keyed by Genius song ID
+type Index map[int]scraper.ScrapedSong
+
+// Difference between a song and its saved version
+type Change struct {
+	Status Status
+	// Saved version of the song, nil when [Added]
+	Saved *scraper.ScrapedSong
+	// Metadata fields that changed when [Updated]
+	Fields []string
+}
+
+// Indexes saved songs by Genius song ID. When a song was saved more than once, the
+// first is kept.
+func NewIndex(songs []scraper.ScrapedSong) Index {
+	index := Index{}
+	for _, song := range songs {
+		if _, ok := index[song.Song.ID]; !ok {
+			index[song.Song.ID] = song
+		}
+	}
+	return index
+}
+
+// Compares the song's title, lyrics state and album with its saved version
+func (i Index) Compare(song genius.SongWithExtras) Change {
+	saved, ok := i[song.ID]
+	if !ok {
+		return Change{Status: Added}
+	}
+
+	fields := []string{}
+	if song.Title != saved.Song.Title {
+		fields = append(fields, Title)
+	}
+	if song.LyricsState != saved.Song.LyricsState {
+		fields = append(fields, LyricsState)
+	}
+	if albumId(song) != albumId(saved.Song) {
+		fields = append(fields, Album)
+	}
+
+	if len(fields) == 0 {
+		return Change{Status: Unchanged, Saved: &saved}
+	}
+	return Change{Status: Updated, Saved: &saved, Fields: fields}
+}
+
+func albumId(song genius.SongWithExtras) int {
+	if song.Album == nil {
+		return 0
+	}
+	return song.Album.ID
+}
+
+// A field whose saved value differs from its scraped value
+type Field struct {
+	Name  string `json:"name"`
+	Saved string `json:"saved"`
+	New   string `json:"new"`
+}
+
+// A lyric line removed from the saved lyrics or added by the scraped lyrics
+type Line struct {
+	// "-" for a removed line, "+" for an added line
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// Difference between a scraped song and its saved version
+type SongDiff struct {
+	SongID int    `json:"song_id"`
+	Title  string `json:"title"`
+	Status Status `json:"status"`
+	// ID of the saved copy when [Duplicate]
+	SavedID string `json:"saved_id,omitempty"`
+	// Fields that changed when [Updated]
+	Fields []Field `json:"fields"`
+	// Lyric lines that changed when [Updated]
+	Lyrics []Line `json:"lyrics"`
+}
+
+// Compares the scraped song with its saved version field by field, including a line
+// diff of the lyrics. A song whose ID differs from its saved version's, i.e. a song not
+// seeded with INCREMENTAL, is [Duplicate].
+func (i Index) Diff(song scraper.ScrapedSong) SongDiff {
+	d := SongDiff{SongID: song.Song.ID, Title: song.Song.FullTitle, Status: Added, Fields: []Field{}, Lyrics: []Line{}}
+	saved, ok := i[song.Song.ID]
+	if !ok {
+		return d
+	}
+	if saved.ID != song.ID {
+		d.Status = Duplicate
+		d.SavedID = saved.ID
+		return d
+	}
+
+	fields := []Field{
+		{Title, saved.Song.Title, song.Song.Title},
+		{LyricsState, saved.Song.LyricsState, song.Song.LyricsState},
+		{Album, albumName(saved.Song), albumName(song.Song)},
+		{"lyric_status", string(saved.LyricStatus), string(song.LyricStatus)},
+		{"category", string(saved.Category), string(song.Category)},
+		{"referents", fmt.Sprint(len(saved.Referents)), fmt.Sprint(len(song.Referents))},
+	}
+	for _, f := range fields {
+		if f.Saved != f.New {
+			d.Fields = append(d.Fields, f)
+		}
+	}
+	d.Lyrics = Lines(saved.Lyrics, song.Lyrics)
+
+	d.Status = Unchanged
+	if len(d.Fields) > 0 || len(d.Lyrics) > 0 {
+		d.Status = Updated
+	}
+	return d
+}
+
+// Lines removed from saved and added in scraped, in lyric order. Lines common to both,
+// by longest common subsequence, are omitted.
+func Lines(saved []string, scraped []string) []Line {
+	// lcs[i][j] is the length of the longest common subsequence of saved[i:] and scraped[j:]
+	lcs := make([][]int, len(saved)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(scraped)+1)
+	}
+	for i := len(saved) - 1; i >= 0; i-- {
+		for j := len(scraped) - 1; j >= 0; j-- {
+			if saved[i] == scraped[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	lines := []Line{}
+	i, j := 0, 0
+	for i < len(saved) || j < len(scraped) {
+		switch {
+		case i < len(saved) && j < len(scraped) && saved[i] == scraped[j]:
+			i++
+			j++
+		case j == len(scraped) || (i < len(saved) && lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, Line{"-", saved[i]})
+			i++
+		default:
+			lines = append(lines, Line{"+", scraped[j]})
+			j++
+		}
+	}
+	return lines
+}
+
+// Saved songs whose Genius song IDs are not listed, e.g. songs deleted, merged or
+// re-attributed on Genius.com, ordered by title. Every copy of a song saved more than
+// once is returned.
+func Orphans(saved []scraper.ScrapedSong, listed map[int]bool) []scraper.ScrapedSong {
+	orphans := []scraper.ScrapedSong{}
+	for _, song := range saved {
+		if !listed[song.Song.ID] {
+			orphans = append(orphans, song)
+		}
+	}
+
+	slices.SortFunc(orphans, func(a, b scraper.ScrapedSong) int {
+		if c := strings.Compare(a.Song.Title, b.Song.Title); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return orphans
+}
+
+func albumName(song genius.SongWithExtras) string {
+	if song.Album == nil {
+		return ""
+	}
+	return song.Album.Name
+}
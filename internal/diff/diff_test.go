@@ -0,0 +1,142 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jseashell/lyrics-db-seeder/internal/genius"
+	"github.com/jseashell/lyrics-db-seeder/internal/scraper"
+)
+
+func newSong(id int, title string, lyricsState string, albumId int) genius.SongWithExtras {
+	song := genius.SongWithExtras{Song: genius.Song{ID: id, Title: title, LyricsState: lyricsState}}
+	if albumId > 0 {
+		song.Album = &genius.Album{ID: albumId}
+	}
+	return song
+}
+
+func Test_Index_Compare(t *testing.T) {
+	index := NewIndex([]scraper.ScrapedSong{
+		{ID: "a", Song: newSong(1, "Best Friend", "complete", 10)},
+		{ID: "b", Song: newSong(2, "Unreleased", "unreleased", 0)},
+		{ID: "c", Song: newSong(1, "Duplicate", "complete", 10)},
+	})
+
+	tests := []struct {
+		name   string
+		song   genius.SongWithExtras
+		status Status
+		fields []string
+		saved  string
+	}{
+		{"unchanged", newSong(1, "Best Friend", "complete", 10), Unchanged, nil, "a"},
+		{"added", newSong(3, "New", "complete", 0), Added, nil, ""},
+		{"lyrics state", newSong(2, "Unreleased", "complete", 0), Updated, []string{LyricsState}, "b"},
+		{"title and album", newSong(1, "Best Friend (Remix)", "complete", 11), Updated, []string{Title, Album}, "a"},
+		{"album removed", newSong(1, "Best Friend", "complete", 0), Updated, []string{Album}, "a"},
+	}
+
+	for _, tt := range tests {
+		change := index.Compare(tt.song)
+		if change.Status != tt.status {
+			t.Errorf("%s: want %q got %q", tt.name, tt.status, change.Status)
+		}
+		if len(change.Fields) > 0 || len(tt.fields) > 0 {
+			if !reflect.DeepEqual(tt.fields, change.Fields) {
+				t.Errorf("%s: want %v got %v", tt.name, tt.fields, change.Fields)
+			}
+		}
+
+		saved := ""
+		if change.Saved != nil {
+			saved = change.Saved.ID
+		}
+		if saved != tt.saved {
+			t.Errorf("%s: want %q got %q", tt.name, tt.saved, saved)
+		}
+	}
+}
+
+func Test_Lines(t *testing.T) {
+	saved := []string{"a", "b", "c", "d"}
+	scraped := []string{"a", "c", "x", "d", "e"}
+
+	want := []Line{{"-", "b"}, {"+", "x"}, {"+", "e"}}
+	if got := Lines(saved, scraped); !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v got %v", want, got)
+	}
+
+	// Equal lyrics
+	if got := Lines(saved, saved); len(got) != 0 {
+		t.Errorf("want none got %v", got)
+	}
+	// New lyrics
+	want = []Line{{"+", "a"}}
+	if got := Lines(nil, []string{"a"}); !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v got %v", want, got)
+	}
+}
+
+func Test_Index_Diff(t *testing.T) {
+	index := NewIndex([]scraper.ScrapedSong{
+		{ID: "a", Song: newSong(1, "Best Friend", "complete", 10), Lyrics: []string{"one", "two"}, LyricStatus: scraper.Complete},
+	})
+
+	added := index.Diff(scraper.ScrapedSong{Song: newSong(2, "New", "complete", 0)})
+	if added.Status != Added {
+		t.Errorf("want %q got %q", Added, added.Status)
+	}
+
+	unchanged := index.Diff(scraper.ScrapedSong{ID: "a", Song: newSong(1, "Best Friend", "complete", 10), Lyrics: []string{"one", "two"}, LyricStatus: scraper.Complete})
+	if unchanged.Status != Unchanged {
+		t.Errorf("want %q got %q", Unchanged, unchanged.Status)
+	}
+
+	updated := index.Diff(scraper.ScrapedSong{ID: "a", Song: newSong(1, "Best Friend", "complete", 10), Lyrics: []string{"one", "three"}, LyricStatus: scraper.Missing})
+	if updated.Status != Updated {
+		t.Errorf("want %q got %q", Updated, updated.Status)
+	}
+	wantFields := []Field{{"lyric_status", "complete", "missing"}}
+	if !reflect.DeepEqual(wantFields, updated.Fields) {
+		t.Errorf("want %v got %v", wantFields, updated.Fields)
+	}
+	wantLyrics := []Line{{"-", "two"}, {"+", "three"}}
+	if !reflect.DeepEqual(wantLyrics, updated.Lyrics) {
+		t.Errorf("want %v got %v", wantLyrics, updated.Lyrics)
+	}
+}
+
+func Test_Index_Diff_Duplicate(t *testing.T) {
+	index := NewIndex([]scraper.ScrapedSong{{ID: "saved", Song: newSong(1, "Best Friend", "complete", 10)}})
+
+	want := SongDiff{SongID: 1, Status: Duplicate, SavedID: "saved", Fields: []Field{}, Lyrics: []Line{}}
+	got := index.Diff(scraper.ScrapedSong{ID: "new", Song: newSong(1, "Best Friend", "complete", 10)})
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want %+v got %+v", want, got)
+	}
+}
+
+func Test_Orphans(t *testing.T) {
+	saved := []scraper.ScrapedSong{
+		{ID: "a", Song: newSong(1, "Best Friend", "complete", 10)},
+		{ID: "b", Song: newSong(2, "Deleted", "complete", 10)},
+		{ID: "c", Song: newSong(3, "Attributed Elsewhere", "complete", 0)},
+		{ID: "d", Song: newSong(2, "Deleted", "complete", 10)},
+	}
+
+	want := []string{"c", "b", "d"}
+	got := []string{}
+	for _, song := range Orphans(saved, map[int]bool{1: true}) {
+		got = append(got, song.ID)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v got %v", want, got)
+	}
+
+	// Every song listed
+	if orphans := Orphans(saved, map[int]bool{1: true, 2: true, 3: true}); len(orphans) != 0 {
+		t.Errorf("want none got %v", orphans)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 John Schellinger.
+// Use of this file is governed by the MIT license that can
+// be found in the LICENSE.txt file in the project root.
+
+// Package `discover` proposes affiliations by ranking the artist's collaborators.
+package discover
+
+import (
+	"sort"
+
+	"github.com/jseashell/lyrics-db-seeder/internal/genius"
+	"github.com/jseashell/lyrics-db-seeder/internal/names"
+)
+
+// An artist credited alongside the target artist
+type Collaborator struct {
+	ID   int
+	Name string
+	// Songs on which the collaborator is the primary artist
+	Primary int
+	// Songs on which the collaborator is a featured artist
+	Featured int
+	// Distinct songs shared with the target artist
+	Songs int
+}
+
+// Counts the primary and featured collaborators on songs credited to the given artist.
+// Collaborators are ranked by shared songs, most first.
+func Collaborators(songs []genius.Song, artist names.Matcher) []Collaborator {
+	collaborators := map[int]*Collaborator{}
+	counted := map[int]map[int]bool{}
+
+	count := func(a genius.Artist, songId int, primary bool) {
+		if artist.Is(a.Name) {
+			return
+		}
+
+		c, ok := collaborators[a.ID]
+		if !ok {
+			c = &Collaborator{ID: a.ID, Name: a.Name}
+			collaborators[a.ID] = c
+			counted[a.ID] = map[int]bool{}
+		}
+
+		if primary {
+			c.Primary++
+		} else {
+			c.Featured++
+		}
+
+		if !counted[a.ID][songId] {
+			counted[a.ID][songId] = true
+			c.Songs++
+		}
+	}
+
+	for _, song := range songs {
+		if !isCredited(song, artist) {
+			continue
+		}
+
+		count(song.PrimaryArtist, song.ID, true)
+		for _, featured := range song.FeaturedArtists {
+			count(featured, song.ID, false)
+		}
+	}
+
+	ranked := []Collaborator{}
+	for _, c := range collaborators {
+		ranked = append(ranked, *c)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Songs != ranked[j].Songs {
+			return ranked[i].Songs > ranked[j].Songs
+		}
+		return ranked[i].Name < ranked[j].Name
+	})
+
+	return ranked
+}
+
+// Names of the top n collaborators, or all collaborators when there are fewer than n
+func Top(collaborators []Collaborator, n int) []string {
+	top := []string{}
+	for i := 0; i < n && i < len(collaborators); i++ {
+		top = append(top, collaborators[i].Name)
+	}
+	return top
+}
+
+// Reports whether the artist is the primary or a featured artist on the song
+func isCredited(song genius.Song, artist names.Matcher) bool {
+	if artist.Is(song.PrimaryArtist.Name) {
+		return true
+	}
+
+	for _, featured := range song.FeaturedArtists {
+		if artist.Is(featured.Name) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,37 @@
+package discover
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jseashell/lyrics-db-seeder/internal/genius"
+	"github.com/jseashell/lyrics-db-seeder/internal/names"
+)
+
+func Test_Collaborators(t *testing.T) {
+	foo := genius.Artist{ID: 1, Name: "Foo"}
+	bar := genius.Artist{ID: 2, Name: "Bar"}
+	baz := genius.Artist{ID: 3, Name: "Baz"}
+	qux := genius.Artist{ID: 4, Name: "Qux"}
+
+	songs := []genius.Song{
+		{ID: 10, PrimaryArtist: foo, FeaturedArtists: []genius.Artist{bar, baz}},
+		{ID: 11, PrimaryArtist: foo, FeaturedArtists: []genius.Artist{bar}},
+		{ID: 12, PrimaryArtist: bar, FeaturedArtists: []genius.Artist{foo}},
+		{ID: 13, PrimaryArtist: qux, FeaturedArtists: []genius.Artist{bar}},
+	}
+
+	want := []Collaborator{
+		{ID: 2, Name: "Bar", Primary: 1, Featured: 2, Songs: 3},
+		{ID: 3, Name: "Baz", Primary: 0, Featured: 1, Songs: 1},
+	}
+	got := Collaborators(songs, names.New("foo"))
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want %v got %v", want, got)
+	}
+
+	if top := Top(got, 1); !reflect.DeepEqual(top, []string{"Bar"}) {
+		t.Fatalf("want %v got %v", []string{"Bar"}, top)
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright 2024 John Schellinger.
+// Use of this file is governed by the MIT license that can
+// be found in the LICENSE.txt file in the project root.
+
+// Package `filter` classifies Genius.com pages so that non-song pages (tracklists,
+// interviews, snippets, translations, etc.) can be excluded or tagged before scraping.
+package filter
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jseashell/lyrics-db-seeder/internal/genius"
+)
+
+// Kind of page listed for an artist on Genius.com
+type Category string
+
+const (
+	Song        Category = "song"
+	Tracklist   Category = "tracklist"
+	Interview   Category = "interview"
+	Snippet     Category = "snippet"
+	Skit        Category = "skit"
+	Translation Category = "translation"
+	NoAlbum     Category = "no_album"
+	LyricsState Category = "lyrics_state"
+)
+
+// Matches a song title to a [Category]
+type TitlePattern struct {
+	Category Category
+	Pattern  *regexp.Regexp
+}
+
+// Set of rules used to [Rules.Classify] a song
+type Rules struct {
+	// Title patterns, evaluated in order
+	TitlePatterns []TitlePattern
+	// Classifies songs without an album as [NoAlbum]
+	RequireAlbum bool
+	// Matches the primary artist name of Genius.com translation accounts
+	TranslationArtist *regexp.Regexp
+	// Lyric states (e.g. "unreleased") to classify as [LyricsState]
+	LyricsStates []string
+	// Drops non-song pages when true, otherwise they are only tagged
+	Exclude bool
+}
+
+// The result of classifying a song
+type Classification struct {
+	Category Category
+	// Human-readable explanation of the category
+	Reason string
+}
+
+// Indicates whether the classified page is a regular song
+func (c Classification) IsSong() bool {
+	return c.Category == Song
+}
+
+// Default title patterns for known non-song pages
+func DefaultTitlePatterns() []TitlePattern {
+	return []TitlePattern{
+		{Tracklist, regexp.MustCompile(`(?i)tracklist|album art`)},
+		{Interview, regexp.MustCompile(`(?i)\binterview\b|\bin conversation\b`)},
+		{Snippet, regexp.MustCompile(`(?i)\bsnippets?\b`)},
+		{Skit, regexp.MustCompile(`(?i)\bskit\b`)},
+		// Genius.com translation suffix, e.g. "Hot (English Translation)" or "Hot (Traducción al Español)"
+		{Translation, regexp.MustCompile(`(?i)\((?:\pL+ )?(?:translation|traducci[oó]n|traduction|tradução|übersetzung|romanized)(?: [^()]*)?\)$`)},
+	}
+}
+
+// Default rules, excluding tracklists, interviews, snippets, skits and translations
+func DefaultRules() Rules {
+	return Rules{
+		TitlePatterns:     DefaultTitlePatterns(),
+		TranslationArtist: regexp.MustCompile(`^Genius\b`),
+		Exclude:           true,
+	}
+}
+
+// Builds [Rules] from the environment, falling back to [DefaultRules].
+//
+//   - `FILTER_MODE`: "exclude" (default) or "tag"
+//   - `FILTER_TITLE_PATTERNS`: semicolon delimited `category=regex` pairs, evaluated before the defaults
+//   - `FILTER_REQUIRE_ALBUM`: classify songs without an album
+//   - `FILTER_TRANSLATION_ARTIST`: regex for translation account names
+//   - `FILTER_LYRICS_STATES`: comma delimited lyric states to classify
+func RulesFromEnv() (Rules, error) {
+	rules := DefaultRules()
+
+	if mode := os.Getenv("FILTER_MODE"); mode != "" {
+		switch mode {
+		case "exclude":
+			rules.Exclude = true
+		case "tag":
+			rules.Exclude = false
+		default:
+			return rules, fmt.Errorf("invalid FILTER_MODE %q", mode)
+		}
+	}
+
+	if s := os.Getenv("FILTER_TITLE_PATTERNS"); s != "" {
+		patterns, err := ParseTitlePatterns(s)
+		if err != nil {
+			return rules, err
+		}
+		rules.TitlePatterns = append(patterns, rules.TitlePatterns...)
+	}
+
+	rules.RequireAlbum, _ = strconv.ParseBool(os.Getenv("FILTER_REQUIRE_ALBUM"))
+
+	if s := os.Getenv("FILTER_TRANSLATION_ARTIST"); s != "" {
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return rules, fmt.Errorf("invalid FILTER_TRANSLATION_ARTIST: %w", err)
+		}
+		rules.TranslationArtist = re
+	}
+
+	if s := os.Getenv("FILTER_LYRICS_STATES"); s != "" {
+		rules.LyricsStates = strings.Split(s, ",")
+	}
+
+	return rules, nil
+}
+
+// Parses semicolon delimited `category=regex` pairs
+func ParseTitlePatterns(s string) ([]TitlePattern, error) {
+	patterns := []TitlePattern{}
+	for _, pair := range strings.Split(s, ";") {
+		if pair == "" {
+			continue
+		}
+
+		category, expr, found := strings.Cut(pair, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid title pattern %q, expected category=regex", pair)
+		}
+
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid title pattern %q: %w", pair, err)
+		}
+		patterns = append(patterns, TitlePattern{Category(category), re})
+	}
+	return patterns, nil
+}
+
+// Classifies the given song. Rules are evaluated in the order translation account,
+// title patterns, lyric state, then missing album.
+func (r Rules) Classify(song genius.SongWithExtras) Classification {
+	if r.TranslationArtist != nil && r.TranslationArtist.MatchString(song.PrimaryArtist.Name) {
+		return Classification{Translation, fmt.Sprintf("primary artist %q is a translation account", song.PrimaryArtist.Name)}
+	}
+
+	for _, tp := range r.TitlePatterns {
+		if tp.Pattern.MatchString(song.Title) {
+			return Classification{tp.Category, fmt.Sprintf("title %q matches %q", song.Title, tp.Pattern.String())}
+		}
+	}
+
+	for _, state := range r.LyricsStates {
+		if song.LyricsState == state {
+			return Classification{LyricsState, fmt.Sprintf("lyrics state is %q", song.LyricsState)}
+		}
+	}
+
+	if r.RequireAlbum && song.Album == nil {
+		return Classification{NoAlbum, "song has no album"}
+	}
+
+	return Classification{Song, ""}
+}
+
+// Classifies the given song and reports whether it should be scraped
+func (r Rules) Keep(song genius.SongWithExtras) (Classification, bool) {
+	c := r.Classify(song)
+	if c.IsSong() {
+		return c, true
+	}
+
+	if r.Exclude {
+		slog.Info("Filtered", "category", c.Category, "reason", c.Reason, "song", song.FullTitle)
+		return c, false
+	}
+
+	slog.Debug("Tagged", "category", c.Category, "reason", c.Reason, "song", song.FullTitle)
+	return c, true
+}
@@ -0,0 +1,98 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/jseashell/lyrics-db-seeder/internal/genius"
+)
+
+func Test_Classify_Song(t *testing.T) {
+	song := genius.SongWithExtras{
+		Song: genius.Song{
+			Title:         "Hot",
+			PrimaryArtist: genius.Artist{Name: "Young Thug"},
+		},
+		Album: &genius.Album{Name: "So Much Fun"},
+	}
+	got := DefaultRules().Classify(song)
+	if !got.IsSong() {
+		t.Fatalf("want %q got %q", Song, got.Category)
+	}
+}
+
+func Test_Classify_TitlePatterns(t *testing.T) {
+	tests := map[string]Category{
+		"Slime Season 3 (Tracklist + Album Art)": Tracklist,
+		"Young Thug Interview with Complex":      Interview,
+		"Unreleased Snippets":                    Snippet,
+		"Mr. Skit":                               Skit,
+		"Hot (Traducción al Español)":            Translation,
+		"Hot (English Translation)":              Translation,
+		"Hot (Romanized)":                        Translation,
+		"Lost in Translation":                    Song,
+	}
+
+	rules := DefaultRules()
+	for title, want := range tests {
+		song := genius.SongWithExtras{Song: genius.Song{Title: title}}
+		got := rules.Classify(song).Category
+		if want != got {
+			t.Errorf("%q: want %q got %q", title, want, got)
+		}
+	}
+}
+
+func Test_Classify_TranslationArtist(t *testing.T) {
+	song := genius.SongWithExtras{
+		Song: genius.Song{
+			Title:         "Young Thug - Hot",
+			PrimaryArtist: genius.Artist{Name: "Genius Traducciones al Español"},
+		},
+	}
+	want := Translation
+	got := DefaultRules().Classify(song).Category
+	if want != got {
+		t.Fatalf("want %q got %q", want, got)
+	}
+}
+
+func Test_Classify_LyricsStateAndAlbum(t *testing.T) {
+	rules := DefaultRules()
+	rules.RequireAlbum = true
+	rules.LyricsStates = []string{"unreleased"}
+
+	unreleased := genius.SongWithExtras{Song: genius.Song{Title: "foo", LyricsState: "unreleased"}}
+	if got := rules.Classify(unreleased).Category; got != LyricsState {
+		t.Errorf("want %q got %q", LyricsState, got)
+	}
+
+	noAlbum := genius.SongWithExtras{Song: genius.Song{Title: "foo", LyricsState: "complete"}}
+	if got := rules.Classify(noAlbum).Category; got != NoAlbum {
+		t.Errorf("want %q got %q", NoAlbum, got)
+	}
+}
+
+func Test_Keep_TagMode(t *testing.T) {
+	rules := DefaultRules()
+	rules.Exclude = false
+
+	song := genius.SongWithExtras{Song: genius.Song{Title: "Unreleased Snippets"}}
+	c, keep := rules.Keep(song)
+	if !keep || c.Category != Snippet {
+		t.Fatalf("want kept %q got kept=%t %q", Snippet, keep, c.Category)
+	}
+}
+
+func Test_ParseTitlePatterns(t *testing.T) {
+	patterns, err := ParseTitlePatterns("freestyle=(?i)freestyle;remix=(?i)remix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patterns) != 2 || patterns[1].Category != "remix" {
+		t.Fatalf("unexpected patterns %v", patterns)
+	}
+
+	if _, err := ParseTitlePatterns("nope"); err == nil {
+		t.Fatal("want error got nil")
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2024 John Schellinger.
+// Use of this file is governed by the MIT license that can
+// be found in the LICENSE.txt file in the project root.
+
+package genius
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Attempts per request when Genius.com responds with 429 or 5xx
+const maxAttempts = 3
+
+var client = &http.Client{Timeout: 30 * time.Second}
+
+// Base URL of the Genius.com API, replaced in tests
+var apiURL = "https://api.genius.com"
+
+// Requests sent to the Genius.com API, and requests that were retried
+var requests, retries atomic.Int64
+
+// Number of requests sent to the Genius.com API since the program started
+type RequestCounts struct {
+	// Requests sent, including retries
+	Requests int
+	// Requests that were retried
+	Retries int
+}
+
+// Counts the requests sent to the Genius.com API since the program started
+func Counts() RequestCounts {
+	return RequestCounts{Requests: int(requests.Load()), Retries: int(retries.Load())}
+}
+
+// Rate limiter shared by every request to Genius.com, including scraped pages.
+// Unlimited until [SetRateLimit] is called.
+var limiter = &rateLimiter{}
+
+// Spaces requests evenly to stay under a requests-per-second limit
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func intervalFor(perSecond float64) time.Duration {
+	if perSecond <= 0 {
+		return 0
+	}
+	return time.Duration(float64(time.Second) / perSecond)
+}
+
+// Sets the shared rate limit in requests per second. 0 is unlimited.
+func SetRateLimit(perSecond float64) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	limiter.interval = intervalFor(perSecond)
+}
+
+// Blocks until the shared rate limiter allows another request to Genius.com. Returns
+// the context's error if it is canceled first.
+func Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	limiter.mu.Lock()
+	if limiter.interval == 0 {
+		limiter.mu.Unlock()
+		return nil
+	}
+
+	now := time.Now()
+	if limiter.next.Before(now) {
+		limiter.next = now
+	}
+	wait := limiter.next.Sub(now)
+	limiter.next = limiter.next.Add(limiter.interval)
+	limiter.mu.Unlock()
+
+	return sleep(ctx, wait)
+}
+
+// Sleeps for the given duration, or until the context is canceled
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Sends an authorized GET request to the Genius.com API and decodes the JSON response into v.
+// Rate limited responses and server errors are retried with backoff until the context
+// is canceled.
+func get(ctx context.Context, path string, query url.Values, v any) error {
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		var retry bool
+		retry, err = tryGet(ctx, path, query, v)
+		if !retry || ctx.Err() != nil {
+			return err
+		}
+
+		if attempt < maxAttempts {
+			backoff := time.Duration(attempt*attempt) * time.Second
+			slog.Warn("Retrying request", "path", path, "attempt", attempt, "backoff", backoff.String(), "error", err)
+			retries.Add(1)
+			if err := sleep(ctx, backoff); err != nil {
+				return err
+			}
+		}
+	}
+	return err
+}
+
+// Sends a single request. Reports whether a failed request should be retried.
+func tryGet(ctx context.Context, path string, query url.Values, v any) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL+path, nil)
+	if err != nil {
+		return false, err
+	}
+
+	accessToken := os.Getenv("GENIUS_ACCESS_TOKEN")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+	req.URL.RawQuery = query.Encode()
+
+	if err := Wait(ctx); err != nil {
+		return false, err
+	}
+	requests.Add(1)
+	res, err := client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("request %s failed: %w", req.URL.Path, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= http.StatusInternalServerError {
+		return true, fmt.Errorf("request %s failed: %s", req.URL.Path, res.Status)
+	}
+	if res.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("request %s failed: %s", req.URL.Path, res.Status)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return true, fmt.Errorf("failed to read %s response: %w", req.URL.Path, err)
+	}
+
+	return false, json.Unmarshal(body, v)
+}
@@ -7,15 +7,13 @@
 package genius
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
 	"log/slog"
-	"net/http"
-	"os"
+	"net/url"
 	"strconv"
-	"strings"
-	"sync"
+
+	"github.com/jseashell/lyrics-db-seeder/internal/names"
 )
 
 // Response metadata
@@ -70,6 +68,10 @@ type Song struct {
 	HeaderImageURL string `json:"header_image_url"`
 	// Unique identifier
 	ID int `json:"id"`
+	// Indicates whether Genius.com lists the song as an instrumental
+	Instrumental bool `json:"instrumental"`
+	// Transcription state of the lyrics, e.g. "complete" or "unreleased"
+	LyricsState string `json:"lyrics_state"`
 	// Human-readable release date
 	ReleaseDateForDisplay string `json:"release_date_for_display"`
 	// Song/Album art, typically 1:1 aspect ratio
@@ -97,13 +99,91 @@ type SongByIdResponse struct {
 	} `json:"response"`
 }
 
-// A [Song] with [Album] and [Media] extras
+// A [Song] with [Album], [Media] and credit extras
 type SongWithExtras struct {
 	Song
 	// The [Album] to which this song belongs
 	Album *Album `json:"album"`
 	// External [Media] for consuming this song
 	Media *Media `json:"media"`
+	// [Artist]s credited as writers
+	WriterArtists []Artist `json:"writer_artists"`
+	// [Artist]s credited as producers
+	ProducerArtists []Artist `json:"producer_artists"`
+	// Additional credits, e.g. "Mixing Engineer" or "Label"
+	CustomPerformances []CustomPerformance `json:"custom_performances"`
+	// Samples, interpolations, remixes, covers, etc. Not persisted as-is because related
+	// songs are complete [Song]s, see [SongWithExtras.Links] instead.
+	SongRelationships []SongRelationship `json:"song_relationships" dynamodbav:"-"`
+}
+
+// Types of [SongRelationship]
+const (
+	Samples         = "samples"
+	SampledIn       = "sampled_in"
+	Interpolates    = "interpolates"
+	InterpolatedBy  = "interpolated_by"
+	RemixOf         = "remix_of"
+	RemixedBy       = "remixed_by"
+	CoverOf         = "cover_of"
+	CoveredBy       = "covered_by"
+	LiveVersionOf   = "live_version_of"
+	PerformedLiveAs = "performed_live_as"
+	TranslationOf   = "translation_of"
+	TranslatedAs    = "translations"
+)
+
+// Songs related to a [Song] by the given relationship type
+type SongRelationship struct {
+	// Relationship type, e.g. [Samples] or [RemixOf]
+	RelationshipType string `json:"relationship_type"`
+	// Related [Song]s
+	Songs []Song `json:"songs"`
+}
+
+// Compact reference to a related [Song]
+type SongLink struct {
+	ID          int    `json:"id"`
+	Title       string `json:"title"`
+	ArtistNames string `json:"artist_names"`
+	URL         string `json:"url"`
+}
+
+// Related songs keyed by relationship type. Relationship types without songs are omitted.
+func (s SongWithExtras) Links() map[string][]SongLink {
+	links := map[string][]SongLink{}
+	for _, relationship := range s.SongRelationships {
+		for _, song := range relationship.Songs {
+			link := SongLink{
+				ID:          song.ID,
+				Title:       song.Title,
+				ArtistNames: song.ArtistNames,
+				URL:         song.URL,
+			}
+			links[relationship.RelationshipType] = append(links[relationship.RelationshipType], link)
+		}
+	}
+	return links
+}
+
+// ID of the original song when this song is a remix or live version, otherwise its own ID.
+// Used to group versions of a song with their original.
+func (s SongWithExtras) OriginalID() int {
+	links := s.Links()
+	for _, relationshipType := range []string{RemixOf, LiveVersionOf} {
+		if originals := links[relationshipType]; len(originals) > 0 {
+			return originals[0].ID
+		}
+	}
+	return s.ID
+}
+
+// A labeled credit for one or more [Artist]s
+type CustomPerformance struct {
+	// Role, e.g. "Recorded At" or "Mastering Engineer"
+	Label string `json:"label"`
+	// [Artist]s credited with the role
+	Artists []Artist `json:"artists"`
 }
 
 // Person responsible for composing, recording, and/or performing a [Song]
@@ -129,6 +209,82 @@ type Album struct {
 	URL                   string `json:"url"`
 }
 
+// Response for a /artists/:id request
+type ArtistByIdResponse struct {
+	Meta     GeniusMeta `json:"meta"`
+	Response struct {
+		// Artist identified by the given artist ID
+		Artist Artist `json:"artist"`
+	} `json:"response"`
+}
+
+// Response for a /albums/:id request
+type AlbumByIdResponse struct {
+	Meta     GeniusMeta `json:"meta"`
+	Response struct {
+		// Album identified by the given album ID
+		Album Album `json:"album"`
+	} `json:"response"`
+}
+
+// Response for a /albums/:id/tracks request
+type AlbumTracksResponse struct {
+	Meta     GeniusMeta `json:"meta"`
+	Response struct {
+		// List of tracks for a given album ID, in album order
+		Tracks []Track `json:"tracks"`
+		// Pagination parameter
+		NextPage *int `json:"next_page"`
+	} `json:"response"`
+}
+
+// A [Song]'s position on an [Album]
+type Track struct {
+	// Track number, nil for unnumbered tracks
+	Number *int `json:"number"`
+	// The track's [Song]
+	Song Song `json:"song"`
+}
+
+// Response for a /referents request
+type ReferentsResponse struct {
+	Meta     GeniusMeta `json:"meta"`
+	Response struct {
+		// List of referents for a given song ID
+		Referents []Referent `json:"referents"`
+	} `json:"response"`
+}
+
+// An annotated fragment of a [Song]'s lyrics
+type Referent struct {
+	// Unique identifier, matches the ID parsed from referent links on the song page
+	ID int `json:"id"`
+	// Annotated lyric text
+	Fragment string `json:"fragment"`
+	// Relative path to the referent
+	Path string `json:"path"`
+	// [Annotation]s explaining the fragment
+	Annotations []Annotation `json:"annotations"`
+}
+
+// An explanation of a [Referent]
+type Annotation struct {
+	// Unique identifier
+	ID int `json:"id"`
+	// Annotation text
+	Body struct {
+		Plain string `json:"plain"`
+	} `json:"body"`
+	// Net votes
+	VotesTotal int `json:"votes_total"`
+	// Indicates whether the annotation was verified by the artist
+	Verified bool `json:"verified"`
+	// Review state, e.g. "accepted" or "pending"
+	State string `json:"state"`
+	// Absolute Genius.com URL
+	URL string `json:"url"`
+}
+
 // A consumable piece of digital media representing a [Song]
 type Media struct {
 	Provider string `json:"provider"`
@@ -138,140 +294,162 @@ type Media struct {
 }
 
 // Searches the Genius.com API for the given search term
-func Search(searchTerm string) SearchResponse {
-	path := "/search"
-	url := fmt.Sprintf("https://api.genius.com%s", path)
-	req, _ := http.NewRequest("GET", url, nil)
-
-	accessToken := os.Getenv("GENIUS_ACCESS_TOKEN")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
-
-	query := req.URL.Query()
+func Search(ctx context.Context, searchTerm string) SearchResponse {
+	query := url.Values{}
 	query.Add("q", searchTerm)
-	req.URL.RawQuery = query.Encode()
-
-	client := &http.Client{}
-	slog.Debug("Search", "url", req.URL.String())
-	res, err := client.Do(req)
-	if err != nil {
-		slog.Error("Failed request.", "error", err)
-	}
-
-	defer res.Body.Close()
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		slog.Error("Failed to read buffer.", "error", err)
-	}
 
 	var data SearchResponse
-	json.Unmarshal(body, &data)
+	slog.Debug("Search", "q", searchTerm)
+	if err := get(ctx, "/search", query, &data); err != nil {
+		slog.Error("Failed request.", "q", searchTerm, "error", err)
+	}
 	return data
 }
 
-// Fetches a page of songs for a given artist via GET request to the Genius.com API.
-// Subsequently loops over each song to fetch its metadata via go routines. Only songs
-// that have the given artist present as a primary or featured artist are returned.
-func Songs(artistId int, artistName string, pageNumber int, includeFeatured bool) ([]SongWithExtras, *int) {
-	path := fmt.Sprintf("/artists/%s/songs", strconv.Itoa(artistId))
-	url := fmt.Sprintf("https://api.genius.com%s", path)
-	req, _ := http.NewRequest("GET", url, nil)
+// Reports whether the given artist is present on the song as its primary artist, or as
+// a featured artist when including featured songs.
+func Contributes(song SongWithExtras, artist names.Matcher, includeFeatured bool) bool {
+	if artist.Matches(song.PrimaryArtist.Name) {
+		slog.Info("As primary artist", "song", song)
+		return true
+	}
 
-	accessToken := os.Getenv("GENIUS_ACCESS_TOKEN")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+	if includeFeatured {
+		for _, feature := range song.FeaturedArtists {
+			if artist.Matches(feature.Name) {
+				slog.Info("As featured artist", "song", song)
+				return true
+			}
+		}
+	}
+
+	slog.Debug("Not a contributor", "song", song)
+	return false
+}
 
-	query := req.URL.Query()
+// Fetches a page of songs for a given artist via GET request to the Genius.com API,
+// without fetching each song's metadata. Returns the next page number, or nil on the last page.
+func SongsPage(ctx context.Context, artistId int, pageNumber int) ([]Song, *int, error) {
+	query := url.Values{}
 	if pageNumber > 0 {
 		query.Add("page", strconv.Itoa(pageNumber))
 	}
 	maxPageSize := "50"
 	query.Add("per_page", maxPageSize)
-	req.URL.RawQuery = query.Encode()
 
-	client := &http.Client{}
-	slog.Debug("Songs", "url", req.URL.String())
-	res, err := client.Do(req)
-	if err != nil {
-		slog.Error("Reqeust failed.", "url", req.URL.String(), "error", err)
+	var data SongsResponse
+	if err := get(ctx, fmt.Sprintf("/artists/%d/songs", artistId), query, &data); err != nil {
+		return nil, nil, err
 	}
 
-	defer res.Body.Close()
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		slog.Error("Failed to read buffer.", "url", req.URL.String(), "error", err)
+	slog.Debug("Songs", slog.Int("artist_id", artistId), slog.Int("page", pageNumber), "count", len(data.Response.Songs))
+	return data.Response.Songs, data.Response.NextPage, nil
+}
+
+// Fetches every page of songs for a given artist, without fetching each song's metadata.
+func AllSongs(ctx context.Context, artistId int) ([]Song, error) {
+	songs := []Song{}
+	pageNumber := 0
+	for {
+		page, nextPage, err := SongsPage(ctx, artistId, pageNumber)
+		if err != nil {
+			return songs, err
+		}
+
+		songs = append(songs, page...)
+		if nextPage == nil {
+			return songs, nil
+		}
+		pageNumber = *nextPage
 	}
+}
 
-	var data SongsResponse
-	json.Unmarshal(body, &data)
-
-	songs := []SongWithExtras{}
-
-	var wg sync.WaitGroup
-	mu := sync.Mutex{}
-	for _, song := range data.Response.Songs {
-		wg.Add(1)
-		go func(songId int) {
-			defer wg.Done()
-			song := SongById(songId)
-
-			if strings.Contains(song.PrimaryArtist.Name, artistName) {
-				slog.Info("As primary artist", "song", song)
-
-				mu.Lock()
-				songs = append(songs, song)
-				mu.Unlock()
-
-				return
-			} else if includeFeatured {
-				foundFeature := false
-				for _, feature := range song.FeaturedArtists {
-					if strings.Contains(feature.Name, artistName) {
-						slog.Info("As featured artist", "song", song)
-
-						mu.Lock()
-						songs = append(songs, song)
-						mu.Unlock()
-
-						foundFeature = true
-						break
-					}
-				}
-
-				if !foundFeature {
-					slog.Debug("Not a contributor", "song", song)
-				}
-			}
-		}(song.ID)
+// Fetches a song identified by the given ID via GET request to the Genius.com API.
+func SongById(ctx context.Context, id int) SongWithExtras {
+	var data SongByIdResponse
+	if err := get(ctx, fmt.Sprintf("/songs/%d", id), url.Values{}, &data); err != nil {
+		slog.Error("Request failed.", slog.Int("song_id", id), "error", err)
 	}
-	wg.Wait()
 
-	return songs, data.Response.NextPage
+	slog.Debug("SongById", slog.Int("song_id", id), "res", data.Response.Song)
+
+	return data.Response.Song
 }
 
-// Fetches a song identified by the given ID via GET request to the Genius.com API.
-func SongById(id int) SongWithExtras {
-	path := fmt.Sprintf("/songs/%d", id)
-	url := fmt.Sprintf("https://api.genius.com%s", path)
-	req, _ := http.NewRequest("GET", url, nil)
-
-	accessToken := os.Getenv("GENIUS_ACCESS_TOKEN")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
-
-	client := &http.Client{}
-	res, err := client.Do(req)
-	if err != nil {
-		slog.Error("Request failed.", "url", req.URL.String(), "error", err)
+// Fetches all referents (annotated fragments) for a song identified by the given ID
+// via paginated GET requests to the Genius.com API. Returns no referents if any page
+// fails, rather than a partial list.
+func Referents(ctx context.Context, songId int) ([]Referent, error) {
+	referents := []Referent{}
+	maxPageSize := 50
+
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Add("song_id", strconv.Itoa(songId))
+		query.Add("text_format", "plain")
+		query.Add("per_page", strconv.Itoa(maxPageSize))
+		query.Add("page", strconv.Itoa(page))
+
+		var data ReferentsResponse
+		if err := get(ctx, "/referents", query, &data); err != nil {
+			return nil, fmt.Errorf("referents page %d: %w", page, err)
+		}
+
+		referents = append(referents, data.Response.Referents...)
+		if len(data.Response.Referents) < maxPageSize {
+			break
+		}
 	}
 
-	defer res.Body.Close()
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		slog.Error("Failed to read buffer.", "url", req.URL.String(), "error", err)
+	slog.Debug("Referents", "song_id", songId, "count", len(referents))
+	return referents, nil
+}
+
+// Fetches an artist identified by the given ID via GET request to the Genius.com API.
+func ArtistById(ctx context.Context, id int) (Artist, error) {
+	var data ArtistByIdResponse
+	if err := get(ctx, fmt.Sprintf("/artists/%d", id), url.Values{}, &data); err != nil {
+		return Artist{}, err
 	}
 
-	var data SongByIdResponse
-	json.Unmarshal(body, &data)
+	slog.Debug("ArtistById", "artist_id", id, "res", data.Response.Artist)
+	return data.Response.Artist, nil
+}
+
+// Fetches an album identified by the given ID via GET request to the Genius.com API.
+func AlbumById(ctx context.Context, id int) (Album, error) {
+	var data AlbumByIdResponse
+	if err := get(ctx, fmt.Sprintf("/albums/%d", id), url.Values{}, &data); err != nil {
+		return Album{}, err
+	}
 
-	slog.Debug("SongById", "url", req.URL.String(), "res", data.Response.Song)
+	slog.Debug("AlbumById", "album_id", id, "res", data.Response.Album)
+	return data.Response.Album, nil
+}
 
-	return data.Response.Song
+// Fetches all tracks for an album identified by the given ID via paginated GET
+// requests to the Genius.com API. Tracks are returned in album order.
+func AlbumTracks(ctx context.Context, id int) ([]Track, error) {
+	tracks := []Track{}
+	maxPageSize := 50
+
+	for page := 1; ; {
+		query := url.Values{}
+		query.Add("per_page", strconv.Itoa(maxPageSize))
+		query.Add("page", strconv.Itoa(page))
+
+		var data AlbumTracksResponse
+		if err := get(ctx, fmt.Sprintf("/albums/%d/tracks", id), query, &data); err != nil {
+			return tracks, err
+		}
+
+		tracks = append(tracks, data.Response.Tracks...)
+		if data.Response.NextPage == nil {
+			break
+		}
+		page = *data.Response.NextPage
+	}
+
+	slog.Debug("AlbumTracks", "album_id", id, "count", len(tracks))
+	return tracks, nil
 }
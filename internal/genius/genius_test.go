@@ -0,0 +1,179 @@
+package genius
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jseashell/lyrics-db-seeder/internal/names"
+)
+
+func Test_Links(t *testing.T) {
+	song := SongWithExtras{
+		Song: Song{ID: 1},
+		SongRelationships: []SongRelationship{
+			{RelationshipType: Samples, Songs: []Song{{ID: 2, Title: "foo", ArtistNames: "bar", URL: "https://genius.com/bar-foo-lyrics"}}},
+			{RelationshipType: CoverOf, Songs: []Song{}},
+		},
+	}
+	want := map[string][]SongLink{
+		Samples: {{ID: 2, Title: "foo", ArtistNames: "bar", URL: "https://genius.com/bar-foo-lyrics"}},
+	}
+	got := song.Links()
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want %v got %v", want, got)
+	}
+}
+
+func Test_OriginalID(t *testing.T) {
+	original := SongWithExtras{Song: Song{ID: 1}}
+	if got := original.OriginalID(); got != 1 {
+		t.Fatalf("want %d got %d", 1, got)
+	}
+
+	remix := SongWithExtras{
+		Song: Song{ID: 3},
+		SongRelationships: []SongRelationship{
+			{RelationshipType: RemixOf, Songs: []Song{{ID: 1}}},
+		},
+	}
+	if got := remix.OriginalID(); got != 1 {
+		t.Fatalf("want %d got %d", 1, got)
+	}
+}
+
+func Test_Contributes(t *testing.T) {
+	artist := names.New("Young Thug")
+	primary := SongWithExtras{Song: Song{PrimaryArtist: Artist{Name: "Young Thug"}}}
+	featured := SongWithExtras{Song: Song{PrimaryArtist: Artist{Name: "Gunna"}, FeaturedArtists: []Artist{{Name: "Young Thug"}}}}
+	other := SongWithExtras{Song: Song{PrimaryArtist: Artist{Name: "Gunna"}}}
+
+	if !Contributes(primary, artist, false) {
+		t.Errorf("want primary artist to contribute")
+	}
+	if Contributes(featured, artist, false) || !Contributes(featured, artist, true) {
+		t.Errorf("want featured artist to contribute only when including featured")
+	}
+	if Contributes(other, artist, true) {
+		t.Errorf("want other artist not to contribute")
+	}
+}
+
+func Test_Wait_Canceled(t *testing.T) {
+	SetRateLimit(0.1)
+	defer SetRateLimit(0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	Wait(ctx)
+	err := Wait(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want %v got %v", context.DeadlineExceeded, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("want Wait to return when canceled, waited %s", elapsed)
+	}
+}
+
+// Serves the Genius.com API from the given handler for the duration of the test
+func serve(t *testing.T, handler http.HandlerFunc) {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	original := apiURL
+	apiURL = server.URL
+	t.Cleanup(func() { apiURL = original })
+}
+
+// Writes a page of n referents
+func writeReferents(w http.ResponseWriter, n int) {
+	var data ReferentsResponse
+	data.Response.Referents = make([]Referent, n)
+	json.NewEncoder(w).Encode(data)
+}
+
+func Test_Referents_Pages(t *testing.T) {
+	pages := []int{}
+	serve(t, func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		pages = append(pages, page)
+		if page == 1 {
+			writeReferents(w, 50)
+		} else {
+			writeReferents(w, 3)
+		}
+	})
+
+	referents, err := Referents(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("want nil got %v", err)
+	}
+	if want, got := 53, len(referents); want != got {
+		t.Errorf("want %d got %d", want, got)
+	}
+	if want, got := []int{1, 2}, pages; !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v got %v", want, got)
+	}
+}
+
+func Test_Referents_PageError(t *testing.T) {
+	serve(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "1" {
+			writeReferents(w, 50)
+		} else {
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	})
+
+	referents, err := Referents(context.Background(), 1)
+	if err == nil {
+		t.Fatal("want error got nil")
+	}
+	if referents != nil {
+		t.Fatalf("want no referents got %d", len(referents))
+	}
+}
+
+func Test_SongByIdResponse_Credits(t *testing.T) {
+	b, err := os.ReadFile(filepath.Join("testdata", "song.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var data SongByIdResponse
+	if err := json.Unmarshal(b, &data); err != nil {
+		t.Fatal(err)
+	}
+	song := data.Response.Song
+
+	artist := func(id int, name string, slug string) Artist {
+		return Artist{ID: id, Name: name, ApiPath: fmt.Sprintf("/artists/%d", id), URL: "https://genius.com/artists/" + slug}
+	}
+	wantWriters := []Artist{artist(1, "Foo", "Foo"), artist(3, "Quux", "Quux")}
+	if !reflect.DeepEqual(wantWriters, song.WriterArtists) {
+		t.Errorf("want %+v got %+v", wantWriters, song.WriterArtists)
+	}
+	wantProducers := []Artist{artist(4, "Corge", "Corge")}
+	if !reflect.DeepEqual(wantProducers, song.ProducerArtists) {
+		t.Errorf("want %+v got %+v", wantProducers, song.ProducerArtists)
+	}
+	wantCustom := []CustomPerformance{
+		{Label: "Mixing Engineer", Artists: []Artist{artist(5, "Grault", "Grault")}},
+		{Label: "Label", Artists: []Artist{artist(6, "Garply Records", "Garply-records")}},
+	}
+	if !reflect.DeepEqual(wantCustom, song.CustomPerformances) {
+		t.Errorf("want %+v got %+v", wantCustom, song.CustomPerformances)
+	}
+}
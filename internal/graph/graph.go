@@ -0,0 +1,247 @@
+// Copyright 2024 John Schellinger.
+// Use of this file is governed by the MIT license that can
+// be found in the LICENSE.txt file in the project root.
+
+// Package `graph` builds an artist collaboration graph and exports it as DOT, GraphML
+// or JSON node-link data.
+package graph
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/jseashell/lyrics-db-seeder/internal/genius"
+)
+
+// Supported export formats
+const (
+	DOT     = "dot"
+	GraphML = "graphml"
+	JSON    = "json"
+)
+
+// An artist in the graph
+type Node struct {
+	// Genius.com artist ID
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// Songs crediting the artist
+	Songs int `json:"songs"`
+}
+
+// Collaboration between two artists. Source is always the lower artist ID.
+type Edge struct {
+	Source int `json:"source"`
+	Target int `json:"target"`
+	// Songs crediting both artists
+	Weight int `json:"weight"`
+}
+
+// Undirected artist collaboration graph, weighted by song count
+type Graph struct {
+	Nodes []Node
+	Edges []Edge
+}
+
+// Builds a collaboration graph from the primary and featured artists of each song.
+// Every pair of artists credited on a song is connected. Duplicate songs are counted once.
+func Build(songs []genius.Song) Graph {
+	nodes := map[int]*Node{}
+	edges := map[[2]int]*Edge{}
+	seen := map[int]bool{}
+
+	for _, song := range songs {
+		if seen[song.ID] {
+			continue
+		}
+		seen[song.ID] = true
+
+		credited := map[int]genius.Artist{song.PrimaryArtist.ID: song.PrimaryArtist}
+		for _, featured := range song.FeaturedArtists {
+			credited[featured.ID] = featured
+		}
+
+		ids := []int{}
+		for id, artist := range credited {
+			node, ok := nodes[id]
+			if !ok {
+				node = &Node{ID: id, Name: artist.Name}
+				nodes[id] = node
+			}
+			node.Songs++
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+
+		for i := 0; i < len(ids); i++ {
+			for j := i + 1; j < len(ids); j++ {
+				key := [2]int{ids[i], ids[j]}
+				edge, ok := edges[key]
+				if !ok {
+					edge = &Edge{Source: ids[i], Target: ids[j]}
+					edges[key] = edge
+				}
+				edge.Weight++
+			}
+		}
+	}
+
+	g := Graph{Nodes: []Node{}, Edges: []Edge{}}
+	for _, node := range nodes {
+		g.Nodes = append(g.Nodes, *node)
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool {
+		return g.Nodes[i].ID < g.Nodes[j].ID
+	})
+
+	for _, edge := range edges {
+		g.Edges = append(g.Edges, *edge)
+	}
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].Source != g.Edges[j].Source {
+			return g.Edges[i].Source < g.Edges[j].Source
+		}
+		return g.Edges[i].Target < g.Edges[j].Target
+	})
+
+	return g
+}
+
+// Returns an error unless the format is one of [DOT], [GraphML] or [JSON]
+func CheckFormat(format string) error {
+	switch format {
+	case DOT, GraphML, JSON:
+		return nil
+	default:
+		return fmt.Errorf("invalid graph format %q, expected %q, %q or %q", format, DOT, GraphML, JSON)
+	}
+}
+
+// Writes the graph in the given format, one of [DOT], [GraphML] or [JSON]
+func (g Graph) Write(w io.Writer, format string) error {
+	switch format {
+	case DOT:
+		return g.WriteDOT(w)
+	case GraphML:
+		return g.WriteGraphML(w)
+	case JSON:
+		return g.WriteJSON(w)
+	default:
+		return CheckFormat(format)
+	}
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// Writes the graph in Graphviz DOT format
+func (g Graph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("graph collaborations {\n")
+	for _, node := range g.Nodes {
+		fmt.Fprintf(&b, "  %d [label=\"%s\", songs=%d];\n", node.ID, dotEscaper.Replace(node.Name), node.Songs)
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %d -- %d [weight=%d, label=\"%d\"];\n", edge.Source, edge.Target, edge.Weight, edge.Weight)
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   struct {
+		ID          string        `xml:"id,attr"`
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphMLNode `xml:"node"`
+		Edges       []graphMLEdge `xml:"edge"`
+	} `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+// Writes the graph in GraphML format
+func (g Graph) WriteGraphML(w io.Writer) error {
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "name", For: "node", AttrName: "name", AttrType: "string"},
+			{ID: "songs", For: "node", AttrName: "songs", AttrType: "int"},
+			{ID: "weight", For: "edge", AttrName: "weight", AttrType: "int"},
+		},
+	}
+	doc.Graph.ID = "collaborations"
+	doc.Graph.EdgeDefault = "undirected"
+
+	for _, node := range g.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			ID: fmt.Sprintf("n%d", node.ID),
+			Data: []graphMLData{
+				{Key: "name", Value: node.Name},
+				{Key: "songs", Value: fmt.Sprint(node.Songs)},
+			},
+		})
+	}
+	for _, edge := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			Source: fmt.Sprintf("n%d", edge.Source),
+			Target: fmt.Sprintf("n%d", edge.Target),
+			Data:   []graphMLData{{Key: "weight", Value: fmt.Sprint(edge.Weight)}},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// Writes the graph as JSON node-link data, as read by e.g. NetworkX and D3
+func (g Graph) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Directed   bool     `json:"directed"`
+		Multigraph bool     `json:"multigraph"`
+		Graph      struct{} `json:"graph"`
+		Nodes      []Node   `json:"nodes"`
+		Links      []Edge   `json:"links"`
+	}{
+		Nodes: g.Nodes,
+		Links: g.Edges,
+	})
+}
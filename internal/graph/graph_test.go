@@ -0,0 +1,102 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jseashell/lyrics-db-seeder/internal/genius"
+)
+
+func testGraph() Graph {
+	foo := genius.Artist{ID: 1, Name: "Foo"}
+	bar := genius.Artist{ID: 2, Name: "Bar \"B\""}
+	baz := genius.Artist{ID: 3, Name: "Baz & Co"}
+
+	return Build([]genius.Song{
+		{ID: 10, PrimaryArtist: foo, FeaturedArtists: []genius.Artist{bar, baz}},
+		{ID: 11, PrimaryArtist: bar, FeaturedArtists: []genius.Artist{foo}},
+		{ID: 11, PrimaryArtist: bar, FeaturedArtists: []genius.Artist{foo}},
+	})
+}
+
+func Test_Build(t *testing.T) {
+	want := Graph{
+		Nodes: []Node{{1, "Foo", 2}, {2, "Bar \"B\"", 2}, {3, "Baz & Co", 1}},
+		Edges: []Edge{{1, 2, 2}, {1, 3, 1}, {2, 3, 1}},
+	}
+	got := testGraph()
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want %v got %v", want, got)
+	}
+}
+
+func Test_WriteDOT(t *testing.T) {
+	var b bytes.Buffer
+	if err := testGraph().Write(&b, DOT); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{`2 [label="Bar \"B\"", songs=2];`, `1 -- 2 [weight=2, label="2"];`} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("want %q in %s", want, b.String())
+		}
+	}
+}
+
+func Test_WriteGraphML(t *testing.T) {
+	var b bytes.Buffer
+	if err := testGraph().Write(&b, GraphML); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc graphML
+	if err := xml.Unmarshal(b.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Graph.Nodes) != 3 || len(doc.Graph.Edges) != 3 {
+		t.Fatalf("unexpected graphml %s", b.String())
+	}
+	if doc.Graph.Nodes[2].Data[0].Value != "Baz & Co" {
+		t.Errorf("want %q got %q", "Baz & Co", doc.Graph.Nodes[2].Data[0].Value)
+	}
+}
+
+func Test_WriteJSON(t *testing.T) {
+	var b bytes.Buffer
+	if err := testGraph().Write(&b, JSON); err != nil {
+		t.Fatal(err)
+	}
+
+	var got struct {
+		Nodes []Node `json:"nodes"`
+		Links []Edge `json:"links"`
+	}
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Nodes) != 3 || got.Links[0].Weight != 2 {
+		t.Fatalf("unexpected json %s", b.String())
+	}
+}
+
+func Test_CheckFormat(t *testing.T) {
+	for _, format := range []string{DOT, GraphML, JSON} {
+		if err := CheckFormat(format); err != nil {
+			t.Errorf("%s: want nil got %v", format, err)
+		}
+	}
+	if err := CheckFormat("csv"); err == nil {
+		t.Fatal("want error got nil")
+	}
+}
+
+func Test_Write_InvalidFormat(t *testing.T) {
+	if err := testGraph().Write(&bytes.Buffer{}, "csv"); err == nil {
+		t.Fatal("want error got nil")
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2024 John Schellinger.
+// Use of this file is governed by the MIT license that can
+// be found in the LICENSE.txt file in the project root.
+
+// Package `manifest` loads a roster of artists to seed in a single run from a YAML or JSON file.
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A roster of artists to seed
+type Manifest struct {
+	Artists []Artist `yaml:"artists" json:"artists"`
+}
+
+// An artist to seed and its search settings
+type Artist struct {
+	// Name of the artist, optional when ArtistIDs is set
+	Name string `yaml:"name" json:"name"`
+	// Genius artist IDs, bypassing search when set
+	ArtistIDs []int `yaml:"artist_ids" json:"artist_ids"`
+	// Other names the artist performs under
+	Aliases []string `yaml:"aliases" json:"aliases"`
+	// Affiliated artists to search alongside the artist
+	Affiliations []string `yaml:"affiliations" json:"affiliations"`
+	// Scrape songs on which the artist is featured
+	IncludeFeatured bool `yaml:"include_featured" json:"include_featured"`
+	// Search for songs credited to the artist "and" an affiliation
+	IncludeAnded bool `yaml:"include_anded" json:"include_anded"`
+	// Artist IDs never to collect
+	ExcludeArtistIDs []int `yaml:"exclude_artist_ids" json:"exclude_artist_ids"`
+	// Artist names never to collect
+	ExcludeArtistNames []string `yaml:"exclude_artist_names" json:"exclude_artist_names"`
+}
+
+// Loads and validates a manifest. Files ending in ".json" are parsed as JSON, all others as YAML.
+func Load(path string) (Manifest, error) {
+	var m Manifest
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return m, err
+	}
+
+	if filepath.Ext(path) == ".json" {
+		err = json.Unmarshal(b, &m)
+	} else {
+		err = yaml.Unmarshal(b, &m)
+	}
+	if err != nil {
+		return m, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+
+	return m, m.Validate()
+}
+
+// Reports every artist without a name or artist IDs
+func (m Manifest) Validate() error {
+	if len(m.Artists) == 0 {
+		return errors.New("manifest has no artists")
+	}
+
+	errs := []error{}
+	for i, artist := range m.Artists {
+		if artist.Name == "" && len(artist.ArtistIDs) == 0 {
+			errs = append(errs, fmt.Errorf("artists[%d]: name or artist_ids is required", i))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Display name, falling back to the artist IDs
+func (a Artist) String() string {
+	if a.Name != "" {
+		return a.Name
+	}
+	return fmt.Sprint(a.ArtistIDs)
+}
@@ -0,0 +1,74 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_Load(t *testing.T) {
+	want := Manifest{Artists: []Artist{
+		{Name: "Young Thug", Aliases: []string{"Thugger"}, IncludeFeatured: true},
+		{ArtistIDs: []int{2358}, ExcludeArtistNames: []string{"Foo"}},
+	}}
+
+	tests := []struct {
+		file     string
+		contents string
+	}{
+		{"artists.yaml", `
+artists:
+  - name: Young Thug
+    aliases: [Thugger]
+    include_featured: true
+  - artist_ids: [2358]
+    exclude_artist_names: [Foo]
+`},
+		{"artists.json", `{"artists": [
+  {"name": "Young Thug", "aliases": ["Thugger"], "include_featured": true},
+  {"artist_ids": [2358], "exclude_artist_names": ["Foo"]}
+]}`},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), tt.file)
+		if err := os.WriteFile(path, []byte(tt.contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := Load(path)
+		if err != nil {
+			t.Fatalf("%s: want nil got %v", tt.file, err)
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("%s: want %+v got %+v", tt.file, want, got)
+		}
+	}
+}
+
+func Test_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest Manifest
+		want     string
+	}{
+		{"valid", Manifest{Artists: []Artist{{Name: "Foo"}, {ArtistIDs: []int{1}}}}, ""},
+		{"empty", Manifest{}, "manifest has no artists"},
+		{"unnamed", Manifest{Artists: []Artist{{Name: "Foo"}, {}}}, "artists[1]: name or artist_ids is required"},
+	}
+
+	for _, tt := range tests {
+		err := tt.manifest.Validate()
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("%s: want nil got %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: want %q got %v", tt.name, tt.want, err)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2024 John Schellinger.
+// Use of this file is governed by the MIT license that can
+// be found in the LICENSE.txt file in the project root.
+
+// Package `names` normalizes and matches artist names, so that e.g. "Beyoncé" matches
+// "Beyonce" and "A$AP Rocky" matches "ASAP Rocky".
+package names
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// How an artist name is matched
+type Mode string
+
+const (
+	// Artist name appears anywhere, e.g. "Future" matches "Future Islands"
+	Substring Mode = "substring"
+	// Artist name appears as whole words, e.g. "Future" matches "Future & Drake" but not "Futuristic"
+	Word Mode = "word"
+	// Artist name equals the name, e.g. "Future" matches "Future" only
+	Exact Mode = "exact"
+)
+
+// Parses a [Mode], defaulting to [Substring] when empty
+func ParseMode(s string) (Mode, error) {
+	switch Mode(s) {
+	case "", Substring:
+		return Substring, nil
+	case Word, Exact:
+		return Mode(s), nil
+	default:
+		return "", fmt.Errorf("invalid match mode %q, expected %q, %q or %q", s, Substring, Word, Exact)
+	}
+}
+
+var folder = cases.Fold()
+
+// Removes diacritics, e.g. "é" becomes "e"
+var diacritics = transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// Characters that stand in for letters in artist names, e.g. "A$AP Rocky"
+var lookalikes = strings.NewReplacer("$", "s")
+
+// Normalizes a name for comparison. Case is folded, diacritics are removed, lookalike
+// characters are replaced, separators (e.g. hyphens) become spaces, all other
+// punctuation is removed and whitespace is collapsed.
+func Normalize(s string) string {
+	s = lookalikes.Replace(s)
+	if removed, _, err := transform.String(diacritics, s); err == nil {
+		s = removed
+	}
+	s = folder.String(s)
+
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case unicode.IsSpace(r) || r == '-' || r == '_' || r == '/':
+			b.WriteRune(' ')
+		}
+	}
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// Splits a Genius.com artist names credit, e.g. "Foo, Bar & Baz", into individual names
+func Split(artistNames string) []string {
+	split := []string{}
+	for _, name := range strings.FieldsFunc(artistNames, func(r rune) bool {
+		return r == ',' || r == '&'
+	}) {
+		if name = strings.TrimSpace(name); name != "" {
+			split = append(split, name)
+		}
+	}
+	return split
+}
+
+// Matches text against an artist name and its aliases
+type Matcher struct {
+	// Artist name followed by any aliases, as given
+	names []string
+	// Normalized names
+	normalized []string
+	mode       Mode
+}
+
+// Creates a [Substring] [Matcher] for the given artist name and aliases
+func New(name string, aliases ...string) Matcher {
+	m := Matcher{mode: Substring}
+	for _, n := range append([]string{name}, aliases...) {
+		if normalized := Normalize(n); normalized != "" {
+			m.names = append(m.names, n)
+			m.normalized = append(m.normalized, normalized)
+		}
+	}
+	return m
+}
+
+// Returns a copy of the matcher using the given [Mode]
+func (m Matcher) WithMode(mode Mode) Matcher {
+	m.mode = mode
+	return m
+}
+
+// Artist name the matcher was created with
+func (m Matcher) Name() string {
+	if len(m.names) == 0 {
+		return ""
+	}
+	return m.names[0]
+}
+
+// Artist name followed by any aliases
+func (m Matcher) Names() []string {
+	return m.names
+}
+
+// Reports whether the artist or an alias appears in s according to the matcher's [Mode]
+func (m Matcher) Matches(s string) bool {
+	s = Normalize(s)
+	if s == "" {
+		return false
+	}
+
+	for _, name := range m.normalized {
+		switch m.mode {
+		case Exact:
+			if s == name {
+				return true
+			}
+		case Word:
+			if strings.Contains(" "+s+" ", " "+name+" ") {
+				return true
+			}
+		default:
+			if strings.Contains(s, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Reports whether s is the artist or an alias, regardless of the matcher's [Mode]
+func (m Matcher) Is(s string) bool {
+	return m.WithMode(Exact).Matches(s)
+}
+
+// Reports whether any of the given names [Matcher.Matches]
+func (m Matcher) MatchesAny(names []string) bool {
+	for _, name := range names {
+		if m.Matches(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package names
+
+import "testing"
+
+func Test_Normalize(t *testing.T) {
+	tests := map[string]string{
+		"Beyoncé":         "beyonce",
+		"A$AP Rocky":      "asap rocky",
+		"ASAP Rocky":      "asap rocky",
+		"JAY-Z":           "jay z",
+		"Ty Dolla $ign":   "ty dolla sign",
+		"  Mr.  Foo  ":    "mr foo",
+		"Young Thug & Co": "young thug co",
+		"Straße":          "strasse",
+	}
+
+	for s, want := range tests {
+		if got := Normalize(s); want != got {
+			t.Errorf("%q: want %q got %q", s, want, got)
+		}
+	}
+}
+
+func Test_Matcher_Modes(t *testing.T) {
+	tests := []struct {
+		mode Mode
+		s    string
+		want bool
+	}{
+		{Substring, "Future Islands", true},
+		{Substring, "Futures", true},
+		{Word, "Future Islands", true},
+		{Word, "Futures", false},
+		{Exact, "Future Islands", false},
+		{Exact, "FUTURE", true},
+	}
+
+	for _, tt := range tests {
+		m := New("Future").WithMode(tt.mode)
+		if got := m.Matches(tt.s); tt.want != got {
+			t.Errorf("%s %q: want %t got %t", tt.mode, tt.s, tt.want, got)
+		}
+	}
+}
+
+func Test_Matcher_Aliases(t *testing.T) {
+	m := New("Young Thug", "Thugger", "SEX")
+	if !m.Is("thugger") {
+		t.Error("want alias match")
+	}
+	if m.Is("Young Thugger") {
+		t.Error("want no exact match")
+	}
+	if m.Name() != "Young Thug" {
+		t.Errorf("want %q got %q", "Young Thug", m.Name())
+	}
+}
+
+func Test_Split(t *testing.T) {
+	got := Split("Foo, Bar & Baz")
+	if len(got) != 3 || got[2] != "Baz" {
+		t.Fatalf("unexpected split %q", got)
+	}
+}
+
+func Test_ParseMode(t *testing.T) {
+	if got, _ := ParseMode(""); got != Substring {
+		t.Errorf("want %q got %q", Substring, got)
+	}
+	if _, err := ParseMode("fuzzy"); err == nil {
+		t.Error("want error got nil")
+	}
+}
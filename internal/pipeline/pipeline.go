@@ -0,0 +1,61 @@
+// Copyright 2024 John Schellinger.
+// Use of this file is governed by the MIT license that can
+// be found in the LICENSE.txt file in the project root.
+
+// Package `pipeline` connects processing stages with bounded channels. Each stage runs a
+// fixed number of workers. A worker blocks while the next stage's channel is full, so a
+// slow stage holds back the stages before it instead of buffering their output.
+package pipeline
+
+import "sync"
+
+// Runs fn on every value received from in using the given number of workers. fn sends
+// any number of values to the next stage with emit. The returned channel holds up to
+// size values and is closed once in is closed and every worker returned.
+func Stage[In, Out any](in <-chan In, workers int, size int, fn func(v In, emit func(Out))) <-chan Out {
+	out := make(chan Out, size)
+	emit := func(v Out) {
+		out <- v
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < max(workers, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for v := range in {
+				fn(v, emit)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// Runs fn on every value received from in using the given number of workers, as the
+// last stage of a pipeline. The returned channel is closed once in is closed and every
+// worker returned.
+func Drain[In any](in <-chan In, workers int, fn func(v In)) <-chan struct{} {
+	done := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < max(workers, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for v := range in {
+				fn(v)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	return done
+}
@@ -0,0 +1,74 @@
+package pipeline
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_Stage(t *testing.T) {
+	in := make(chan int)
+	go func() {
+		for i := 1; i <= 10; i++ {
+			in <- i
+		}
+		close(in)
+	}()
+
+	// Drops odd values and doubles even values
+	evens := Stage(in, 3, 2, func(v int, emit func(int)) {
+		if v%2 == 0 {
+			emit(v)
+			emit(v)
+		}
+	})
+
+	mu := sync.Mutex{}
+	got := []int{}
+	<-Drain(evens, 2, func(v int) {
+		mu.Lock()
+		got = append(got, v)
+		mu.Unlock()
+	})
+
+	slices.Sort(got)
+	want := []int{2, 2, 4, 4, 6, 6, 8, 8, 10, 10}
+	if !slices.Equal(want, got) {
+		t.Fatalf("want %v got %v", want, got)
+	}
+}
+
+func Test_Stage_BackPressure(t *testing.T) {
+	in := make(chan int)
+	sent := atomic.Int32{}
+	go func() {
+		for i := 0; i < 100; i++ {
+			in <- i
+			sent.Add(1)
+		}
+		close(in)
+	}()
+
+	release := make(chan struct{})
+	out := Stage(in, 1, 2, func(v int, emit func(int)) {
+		emit(v)
+	})
+	done := Drain(out, 1, func(v int) {
+		<-release
+	})
+
+	// The drain holds 1 value, the channel 2 and the stage worker 1, so the sender is
+	// blocked after 4 or 5 values while the drain is stalled
+	time.Sleep(50 * time.Millisecond)
+	if n := sent.Load(); n > 5 {
+		t.Errorf("want at most 5 got %d", n)
+	}
+
+	close(release)
+	<-done
+	if n := sent.Load(); n != 100 {
+		t.Errorf("want 100 got %d", n)
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2024 John Schellinger.
+// Use of this file is governed by the MIT license that can
+// be found in the LICENSE.txt file in the project root.
+
+// Package `report` collects the counts of a seed run and writes its summary.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+)
+
+// Count that is safe for concurrent use, written as a number in JSON
+type Counter struct {
+	n atomic.Int64
+}
+
+func (c *Counter) Add(n int) {
+	c.n.Add(int64(n))
+}
+
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+func (c *Counter) Load() int {
+	return int(c.n.Load())
+}
+
+func (c *Counter) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.Load())
+}
+
+// Counts keyed by reason that are safe for concurrent use, written as an object in JSON
+type Reasons struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (r *Reasons) Inc(reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.counts == nil {
+		r.counts = map[string]int{}
+	}
+	r.counts[reason]++
+}
+
+// Copy of the counts
+func (r *Reasons) Counts() map[string]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counts := map[string]int{}
+	for reason, n := range r.counts {
+		counts[reason] = n
+	}
+	return counts
+}
+
+func (r *Reasons) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.Counts())
+}
+
+// Summary of a seed run. Counters are updated concurrently while seeding.
+type Summary struct {
+	StartedAt   time.Time `json:"started_at"`
+	Seconds     float64   `json:"seconds"`
+	Interrupted bool      `json:"interrupted"`
+	// Nothing was saved, songs counted as written would have been
+	DryRun bool `json:"dry_run"`
+
+	// Artist IDs found by search or confirmed from ARTIST_IDS
+	ArtistIDsSearched Counter `json:"artist_ids_searched"`
+	// Pages of songs listed
+	PagesFetched Counter `json:"pages_fetched"`
+	// Pages of songs that could not be listed
+	PagesFailed Counter `json:"pages_failed"`
+	// Songs listed on the pages
+	SongsDiscovered Counter `json:"songs_discovered"`
+	// Listed songs already processed by the resumed run
+	SongsResumed Counter `json:"songs_resumed"`
+	// Songs dropped before scraping, by reason in FilterReasons
+	SongsFiltered Counter `json:"songs_filtered"`
+	FilterReasons Reasons `json:"filter_reasons"`
+	// Saved songs skipped because they are unchanged, when incremental
+	SongsUnchanged Counter `json:"songs_unchanged"`
+	SongsScraped   Counter `json:"songs_scraped"`
+	// Scraped songs without lyrics
+	SongsEmpty Counter `json:"songs_empty"`
+	// Scraped songs whose lyrics are unreleased, instrumental or missing, by lyric status,
+	// whether or not they are written
+	LyricStatuses Reasons `json:"lyric_statuses"`
+	SongsWritten  Counter `json:"songs_written"`
+	// Written songs that were not saved before, when incremental
+	SongsAdded Counter `json:"songs_added"`
+	// Written songs whose metadata changed since they were saved, when incremental
+	SongsUpdated  Counter `json:"songs_updated"`
+	SongsFailed   Counter `json:"songs_failed"`
+	AlbumsWritten Counter `json:"albums_written"`
+
+	// Requests sent to the Genius.com API, including retries
+	APICalls int `json:"api_calls"`
+	// Genius.com API requests that were retried
+	Retries int `json:"retries"`
+
+	Artists []Artist `json:"artists"`
+}
+
+// Results of seeding a single artist
+type Artist struct {
+	Name        string  `json:"name"`
+	ArtistIDs   []int   `json:"artist_ids"`
+	Written     int     `json:"written"`
+	Failed      int     `json:"failed"`
+	PagesFailed int     `json:"pages_failed"`
+	Added       int     `json:"added"`
+	Updated     int     `json:"updated"`
+	Unchanged   int     `json:"unchanged"`
+	Seconds     float64 `json:"seconds"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// Creates a summary of a run starting now
+func New() *Summary {
+	return &Summary{StartedAt: time.Now().UTC(), Artists: []Artist{}}
+}
+
+// Prints a table of the run's totals, followed by the filter reasons and lyric statuses
+func (s *Summary) WriteTable(out io.Writer) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	rows := []struct {
+		label string
+		n     int
+	}{
+		{"Artist IDs searched", s.ArtistIDsSearched.Load()},
+		{"Pages fetched", s.PagesFetched.Load()},
+		{"Pages failed", s.PagesFailed.Load()},
+		{"Songs discovered", s.SongsDiscovered.Load()},
+		{"Songs resumed", s.SongsResumed.Load()},
+		{"Songs filtered", s.SongsFiltered.Load()},
+		{"Songs unchanged", s.SongsUnchanged.Load()},
+		{"Songs scraped", s.SongsScraped.Load()},
+		{"Songs with empty lyrics", s.SongsEmpty.Load()},
+		{"Songs written", s.SongsWritten.Load()},
+		{"Songs failed", s.SongsFailed.Load()},
+		{"Albums written", s.AlbumsWritten.Load()},
+		{"API calls", s.APICalls},
+		{"Retries", s.Retries},
+	}
+	for _, row := range rows {
+		fmt.Fprintf(w, "%s\t%d\n", row.label, row.n)
+	}
+
+	reasons := s.FilterReasons.Counts()
+	keys := []string{}
+	for reason := range reasons {
+		keys = append(keys, reason)
+	}
+	slices.Sort(keys)
+	for _, reason := range keys {
+		fmt.Fprintf(w, "  filtered: %s\t%d\n", reason, reasons[reason])
+	}
+
+	statuses := s.LyricStatuses.Counts()
+	keys = []string{}
+	for status := range statuses {
+		keys = append(keys, status)
+	}
+	slices.Sort(keys)
+	for _, status := range keys {
+		fmt.Fprintf(w, "  lyrics: %s\t%d\n", status, statuses[status])
+	}
+
+	fmt.Fprintf(w, "Seconds\t%.1f\n", s.Seconds)
+	if s.Interrupted {
+		fmt.Fprintln(w, "Interrupted\tyes")
+	}
+	if s.DryRun {
+		fmt.Fprintln(w, "Dry run\tyes")
+	}
+	w.Flush()
+}
+
+// Writes the summary as JSON to the given path
+func (s *Summary) WriteFile(path string) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
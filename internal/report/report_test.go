@@ -0,0 +1,77 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func Test_Summary_Counters(t *testing.T) {
+	s := New()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s.SongsWritten.Inc()
+			if i%10 == 0 {
+				s.SongsFiltered.Inc()
+				s.FilterReasons.Inc("tracklist")
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if want, got := 100, s.SongsWritten.Load(); want != got {
+		t.Errorf("want %d got %d", want, got)
+	}
+	if want, got := 10, s.FilterReasons.Counts()["tracklist"]; want != got {
+		t.Errorf("want %d got %d", want, got)
+	}
+}
+
+func Test_Summary_Write(t *testing.T) {
+	s := New()
+	s.SongsDiscovered.Add(3)
+	s.SongsFiltered.Inc()
+	s.FilterReasons.Inc("not credited")
+	s.LyricStatuses.Inc("unreleased")
+	s.APICalls = 7
+	s.Artists = append(s.Artists, Artist{Name: "Young Thug", ArtistIDs: []int{1}, Written: 2})
+
+	var b strings.Builder
+	s.WriteTable(&b)
+	table := strings.Join(strings.Fields(b.String()), " ")
+	for _, want := range []string{"Songs discovered 3", "filtered: not credited 1", "lyrics: unreleased 1", "API calls 7"} {
+		if !strings.Contains(table, want) {
+			t.Errorf("want %q got %q", want, table)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "summary.json")
+	if err := s.WriteFile(path); err != nil {
+		t.Fatalf("want nil got %v", err)
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]any{}
+	if err := json.Unmarshal(contents, &got); err != nil {
+		t.Fatalf("want JSON got %v", err)
+	}
+	if got["songs_discovered"] != 3.0 || got["api_calls"] != 7.0 {
+		t.Errorf("want 3 songs discovered and 7 API calls got %s", contents)
+	}
+	if reasons, _ := got["filter_reasons"].(map[string]any); reasons["not credited"] != 1.0 {
+		t.Errorf("want 1 not credited got %v", got["filter_reasons"])
+	}
+	if statuses, _ := got["lyric_statuses"].(map[string]any); statuses["unreleased"] != 1.0 {
+		t.Errorf("want 1 unreleased got %v", got["lyric_statuses"])
+	}
+}
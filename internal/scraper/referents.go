@@ -0,0 +1,156 @@
+// Copyright 2024 John Schellinger.
+// Use of this file is governed by the MIT license that can
+// be found in the LICENSE.txt file in the project root.
+
+package scraper
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Position of an annotated fragment within scraped lyrics. A fragment that spans
+// multiple lines is recorded once per line.
+type Referent struct {
+	// Genius.com referent ID
+	ID int `json:"id"`
+	// Index into the scraped lyrics
+	Line int `json:"line"`
+	// Character (rune) offset of the first annotated character
+	Start int `json:"start"`
+	// Character (rune) offset after the last annotated character
+	End int `json:"end"`
+}
+
+// Private use characters that survive sanitizing and mark referent boundaries
+// as "<id><fragment>"
+const (
+	referentOpen  = '\uE000'
+	referentID    = '\uE001'
+	referentClose = '\uE002'
+)
+
+// Referent links are relative or absolute paths beginning with the referent ID
+var referentHref = regexp.MustCompile(`^(?:https://genius\.com)?/(\d+)/`)
+
+// Wraps the text of each referent link in markers so the referent ID and span
+// can be recovered after the link itself is sanitized.
+func markReferents(doc *goquery.Document) {
+	doc.Find("a[href]").Each(func(_ int, a *goquery.Selection) {
+		href, _ := a.Attr("href")
+		match := referentHref.FindStringSubmatch(href)
+		if match == nil {
+			return
+		}
+		a.PrependHtml(string(referentOpen) + match[1] + string(referentID))
+		a.AppendHtml(string(referentClose))
+	})
+}
+
+// Run of text within a line, annotated by a referent when referentID is non-zero
+type segment struct {
+	text       string
+	referentID int
+}
+
+type segments []segment
+
+// Splits a marked line into segments. open tracks the referent that is open at the
+// end of the line, because annotated fragments may span line breaks.
+func splitReferents(line string, open *int) segments {
+	ret := segments{}
+	var b strings.Builder
+
+	flush := func() {
+		if b.Len() > 0 {
+			ret = append(ret, segment{b.String(), *open})
+			b.Reset()
+		}
+	}
+
+	for i := 0; i < len(line); {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		switch r {
+		case referentOpen:
+			flush()
+			end := strings.IndexRune(line[i:], referentID)
+			if end < 0 {
+				i += size
+				continue
+			}
+			*open, _ = strconv.Atoi(line[i+size : i+end])
+			i += end + utf8.RuneLen(referentID)
+			continue
+		case referentClose:
+			flush()
+			*open = 0
+		default:
+			b.WriteRune(r)
+		}
+		i += size
+	}
+	flush()
+
+	return ret
+}
+
+// Plain text of the line without markers
+func (s segments) text() string {
+	var b strings.Builder
+	for _, seg := range s {
+		b.WriteString(seg.text)
+	}
+	return b.String()
+}
+
+// Cleans the line into a lyric and returns the span of each annotated segment.
+// [Referent.Line] is left for the caller to set.
+func (s segments) clean() (string, []Referent) {
+	cleaned := make([]string, len(s))
+	for i, seg := range s {
+		cleaned[i] = cleanText(seg.text)
+	}
+	full := strings.Join(cleaned, "")
+
+	trimmed := strings.Trim(full, " ")
+	leadBytes := len(full) - len(strings.TrimLeft(full, " "))
+	if strings.HasPrefix(trimmed, "[") {
+		trimmed = strings.TrimPrefix(trimmed, "[")
+		leadBytes++
+	}
+	trimmed = strings.TrimSuffix(trimmed, "]")
+
+	// Offset of the trimmed lyric within the full line
+	lead := utf8.RuneCountInString(full[:leadBytes])
+	length := utf8.RuneCountInString(trimmed)
+
+	spans := []Referent{}
+	offset := 0
+	for i, seg := range s {
+		n := utf8.RuneCountInString(cleaned[i])
+		if seg.referentID != 0 {
+			start := max(offset-lead, 0)
+			end := min(offset+n-lead, length)
+			if start < end {
+				spans = append(spans, Referent{ID: seg.referentID, Start: start, End: end})
+			}
+		}
+		offset += n
+	}
+
+	return trimmed, spans
+}
+
+// Replaces unknown fragments and normalizes quotes and escaped characters
+func cleanText(text string) string {
+	text = strings.ReplaceAll(text, "[?]", "___")
+	text = strings.ReplaceAll(text, "’", "'")
+	text = strings.ReplaceAll(text, "&#39;", "'")
+	text = strings.ReplaceAll(text, "&#34;", "\"")
+	text = strings.ReplaceAll(text, "&amp;", "&")
+	return text
+}
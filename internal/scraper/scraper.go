@@ -6,11 +6,22 @@
 package scraper
 
 import (
+	"context"
+	"fmt"
+	"html"
 	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/PuerkitoBio/goquery"
 	"github.com/gocolly/colly"
+	"github.com/jseashell/lyrics-db-seeder/internal/filter"
 	"github.com/jseashell/lyrics-db-seeder/internal/genius"
+	"github.com/jseashell/lyrics-db-seeder/internal/names"
 	"github.com/microcosm-cc/bluemonday"
 )
 
@@ -19,61 +30,397 @@ type ScrapedSong struct {
 	Song   genius.SongWithExtras `json:"song"`
 	Album  genius.Album          `json:"album"`
 	Lyrics []string              `json:"lyrics"`
+	// Page category assigned by the filter, "song" unless tagged as a non-song page
+	Category filter.Category `json:"category"`
+	// Availability of the lyrics at the time of scraping
+	LyricStatus LyricStatus `json:"lyric_status"`
+	// Annotated fragments within [ScrapedSong.Lyrics]
+	Referents []Referent `json:"referents"`
+	// Genius.com referents and their annotations, joined to [ScrapedSong.Referents] by ID
+	Annotations []genius.Referent `json:"annotations"`
+	// Related songs keyed by relationship type, see [genius.SongWithExtras.Links]
+	Relationships map[string][]genius.SongLink `json:"relationships"`
+	// Original song ID for remixes and live versions, otherwise the song's own ID
+	OriginalID int `json:"original_id"`
+	// Set by `reconcile -flag` once the song is no longer listed on Genius.com
+	OrphanedAt *time.Time `json:"orphaned_at,omitempty" dynamodbav:",omitempty"`
 }
 
-func Run(artistName string, song genius.SongWithExtras) []string {
-	lyrics := &[]string{}
+// Availability of a song's lyrics on Genius.com
+type LyricStatus string
+
+const (
+	// Lyrics were scraped
+	Complete LyricStatus = "complete"
+	// Genius.com shows a placeholder until the song is released
+	Unreleased LyricStatus = "unreleased"
+	// The song has no lyrics
+	Instrumental LyricStatus = "instrumental"
+	// No lyrics were found and no placeholder explains why
+	Missing LyricStatus = "missing"
+)
+
+// Placeholder messages shown by Genius.com in place of lyrics
+var placeholders = map[string]LyricStatus{
+	"lyrics for this song have yet to be released": Unreleased,
+	"this song is an instrumental":                 Instrumental,
+}
+
+// Non-lyric nodes Genius.com renders inside lyrics containers, e.g. the
+// "You might also like" recommendations, lyrics header, and embed/share buttons
+var boilerplateSelectors = []string{
+	"[data-exclude-from-selection=\"true\"]",
+	"div[class^=\"RightSidebar\"]",
+	"div[class^=\"SidebarLyrics\"]",
+	"div[class^=\"InreadContainer\"]",
+	"div[class^=\"LyricsHeader\"]",
+	"div[class^=\"ContributorsCreditSong\"]",
+	"div[class^=\"Embed\"]",
+	"div[class^=\"ShareButtons\"]",
+	"button",
+	"script",
+	"style",
+}
+
+// Non-lyric lines that survive node removal
+var boilerplateLines = []*regexp.Regexp{
+	regexp.MustCompile(`^You might also like$`),
+	regexp.MustCompile(`^\d+ Contributors?$`),
+	regexp.MustCompile(`^Read More\s*$`),
+	regexp.MustCompile(`^\d*Embed$`),
+}
+
+// Embed counter glued to the last line of the last lyrics container, e.g. "last line42Embed"
+var embedSuffix = regexp.MustCompile(`\d*Embed$`)
+
+// Lyrics page of a song as fetched by [Fetch]
+type Page struct {
+	// HTML of each lyrics container
+	Containers []string
+	// Text of any lyrics placeholder, e.g. "Lyrics for this song have yet to be released"
+	Placeholder string
+}
+
+// Fetches and parses the lyrics page of the song for the parts by the given artist
+func Run(ctx context.Context, artist names.Matcher, song genius.SongWithExtras) ([]string, []Referent, LyricStatus) {
+	return Fetch(ctx, song).Parse(artist, song)
+}
+
+// Fetches the lyrics containers and any placeholder from the song's lyrics page. The
+// page is empty when the context is canceled first.
+func Fetch(ctx context.Context, song genius.SongWithExtras) Page {
+	page := Page{Containers: []string{}}
 	selector := "div[data-lyrics-container=\"true\"]"
+	placeholderSelector := "div[class^=\"LyricsPlaceholder\"]"
 
-	c := colly.NewCollector()
+	c := newCollector(ctx)
 	c.OnHTML(selector, func(e *colly.HTMLElement) {
 		html, _ := e.DOM.Html()
-		nextLyrics := Parse(artistName, song, html)
-		*lyrics = append(*lyrics, nextLyrics...)
+		page.Containers = append(page.Containers, html)
+	})
+	c.OnHTML(placeholderSelector, func(e *colly.HTMLElement) {
+		page.Placeholder += e.Text
 	})
 	c.Visit(song.URL)
 	c.Wait()
 
-	return *lyrics
+	return page
+}
+
+// Song ID in the metadata of a lyrics page, e.g. `<meta content="genius://songs/123">`
+var songIDMeta = regexp.MustCompile(`(?:genius://songs/|content="/songs/)(\d+)`)
+
+// Song API path, e.g. "https://genius.com/songs/123"
+var songPath = regexp.MustCompile(`^/songs/(\d+)/?$`)
+
+// Finds the Genius.com song ID of a song URL, fetching the lyrics page unless the URL
+// is a song API path, e.g. "https://genius.com/Young-thug-best-friend-lyrics".
+func SongID(ctx context.Context, songURL string) (int, error) {
+	u, err := url.Parse(songURL)
+	if err != nil {
+		return 0, err
+	}
+	if match := songPath.FindStringSubmatch(u.Path); match != nil {
+		return strconv.Atoi(match[1])
+	}
+
+	id := 0
+	c := newCollector(ctx)
+	c.OnResponse(func(r *colly.Response) {
+		id, _ = songIDFromHTML(string(r.Body))
+	})
+	if err := c.Visit(songURL); err != nil {
+		return 0, err
+	}
+	c.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if id == 0 {
+		return 0, fmt.Errorf("no song ID found at %s", songURL)
+	}
+	return id, nil
+}
+
+// Collector whose requests share the Genius.com rate limit and stop when the context
+// is canceled
+func newCollector(ctx context.Context) *colly.Collector {
+	c := colly.NewCollector()
+	c.WithTransport(contextTransport{ctx: ctx, next: http.DefaultTransport})
+	c.OnRequest(func(r *colly.Request) {
+		if err := genius.Wait(ctx); err != nil {
+			r.Abort()
+		}
+	})
+	return c
+}
+
+// Sends each request with the context, which colly does not support
+type contextTransport struct {
+	ctx  context.Context
+	next http.RoundTripper
+}
+
+func (t contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.next.RoundTrip(req.WithContext(t.ctx))
+}
+
+func songIDFromHTML(html string) (int, bool) {
+	match := songIDMeta.FindStringSubmatch(html)
+	if match == nil {
+		return 0, false
+	}
+	id, err := strconv.Atoi(match[1])
+	return id, err == nil
+}
+
+// Parses the lyrics of every container for the parts of the song by the given artist,
+// and determines the [LyricStatus]
+func (p Page) Parse(artist names.Matcher, song genius.SongWithExtras) ([]string, []Referent, LyricStatus) {
+	lyrics := []string{}
+	referents := []Referent{}
+	for i, html := range p.Containers {
+		nextLyrics, nextReferents, _ := parse(artist, html, i == len(p.Containers)-1)
+		for _, referent := range nextReferents {
+			referent.Line += len(lyrics)
+			referents = append(referents, referent)
+		}
+		lyrics = append(lyrics, nextLyrics...)
+	}
+	slog.Debug("Scrape", "song", song)
+
+	status := Status(song, p.Placeholder, lyrics)
+	if status != Complete {
+		slog.Info("Lyrics unavailable", "status", status, "song", song.FullTitle)
+	}
+
+	return lyrics, referents, status
+}
+
+// Sections of every container, including those by other artists
+func (p Page) Sections(artist names.Matcher) []Section {
+	sections := []Section{}
+	for i, html := range p.Containers {
+		_, _, nextSections := parse(artist, html, i == len(p.Containers)-1)
+		sections = append(sections, nextSections...)
+	}
+	return sections
+}
+
+// Determines the [LyricStatus] from the song metadata, any placeholder text found
+// on the page, and the parsed lyrics.
+func Status(song genius.SongWithExtras, placeholder string, lyrics []string) LyricStatus {
+	if song.Instrumental {
+		return Instrumental
+	}
+
+	if status, ok := placeholderStatus(placeholder); ok {
+		return status
+	}
+
+	if len(lyrics) == 0 {
+		if song.LyricsState == string(Unreleased) {
+			return Unreleased
+		}
+		return Missing
+	}
+
+	return Complete
+}
+
+func placeholderStatus(text string) (LyricStatus, bool) {
+	text = strings.ToLower(text)
+	for message, status := range placeholders {
+		if strings.Contains(text, message) {
+			return status, true
+		}
+	}
+	return "", false
+}
+
+// Parses lyrics from a lyrics container for the parts of the song by the given artist
+func Parse(artist names.Matcher, song genius.SongWithExtras, html string) []string {
+	lyrics, _ := ParseWithReferents(artist, song, html)
+	return lyrics
+}
+
+// Same as [Parse], additionally returning the position of each annotated fragment.
+// [Referent.Line] is relative to the returned lyrics.
+func ParseWithReferents(artist names.Matcher, song genius.SongWithExtras, html string) ([]string, []Referent) {
+	lyrics, referents, _ := parse(artist, html, true)
+
+	slog.Debug("Scrape", "song", song)
+	return lyrics, referents
 }
 
-func Parse(artistName string, song genius.SongWithExtras, html string) []string {
+// A part of the lyrics introduced by a section header, e.g. "[Verse 1: Foo & Bar]"
+type Section struct {
+	// Header without brackets, empty for lyrics before the first header
+	Header string `json:"header"`
+	// Artists credited in the header
+	Artists []string `json:"artists"`
+	// Whether the section is by the artist, so its lines are kept
+	Kept bool `json:"kept"`
+	// Lyrics of the section, whether kept or not
+	Lines []string `json:"lines"`
+}
+
+// Parses the lyrics by the given artist, their referents, and every section of a
+// lyrics container. Headers without artists keep the previous section's artists. The
+// last container of a page may end with an embed counter, which is removed.
+func parse(artist names.Matcher, html string, last bool) ([]string, []Referent, []Section) {
+	html = editContainer(html, removeBoilerplate, markReferents)
 	html = strings.ReplaceAll(html, "<br/>", "\n")
 
 	p := bluemonday.NewPolicy()
 	html = p.Sanitize(html)
 	lines := strings.Split(html, "\n")
+	if last {
+		trimEmbedCounter(lines)
+	}
 
 	lyrics := []string{}
+	referents := []Referent{}
+	sections := []Section{}
 	featurePart := false
-	for _, line := range lines {
+	openReferent := 0
+	for _, marked := range lines {
+		segments := splitReferents(marked, &openReferent)
+		line := segments.text()
+
 		// Skip blank lines
 		if line == "" {
 			continue
 		}
 
-		// Skip verses by other artists
-		if isMetaLine(line) && strings.Contains(line, ":") {
-			featurePart = !strings.Contains(line, artistName)
+		// Skip placeholders rendered inside the lyrics container
+		if _, ok := placeholderStatus(line); ok {
 			continue
 		}
 
+		// Skip page boilerplate
+		if isBoilerplateLine(line) {
+			continue
+		}
+
+		if isMetaLine(line) {
+			// Skip verses by other artists
+			artists := sectionArtists(line)
+			if strings.Contains(line, ":") {
+				featurePart = !artist.MatchesAny(artists)
+			} else if len(sections) > 0 {
+				artists = sections[len(sections)-1].Artists
+			}
+			sections = append(sections, Section{Header: sectionHeader(line), Artists: artists, Kept: !featurePart, Lines: []string{}})
+			continue
+		}
+
+		trimmed, spans := segments.clean()
+		if len(sections) == 0 {
+			sections = append(sections, Section{Artists: []string{}, Kept: true, Lines: []string{}})
+		}
+		sections[len(sections)-1].Lines = append(sections[len(sections)-1].Lines, trimmed)
+
 		// Parse lyrics from parts of the song by the defined artist
-		if !isMetaLine(line) && !featurePart {
-			trimmed := strings.Trim(line, " ")
-			trimmed = strings.ReplaceAll(trimmed, "[?]", "___")
-			trimmed = strings.TrimPrefix(trimmed, "[")
-			trimmed = strings.TrimSuffix(trimmed, "]")
-			trimmed = strings.ReplaceAll(trimmed, "’", "'")
-			trimmed = strings.ReplaceAll(trimmed, "&#39;", "'")
-			trimmed = strings.ReplaceAll(trimmed, "&#34;", "\"")
-			trimmed = strings.ReplaceAll(trimmed, "&amp;", "&")
+		if !featurePart {
+			for _, span := range spans {
+				span.Line = len(lyrics)
+				referents = append(referents, span)
+			}
 			lyrics = append(lyrics, trimmed)
 		}
 	}
 
-	slog.Debug("Scrape", "song", song)
-	return lyrics
+	return lyrics, referents, sections
+}
+
+// Removes known non-lyric nodes from a lyrics container. Returns the given
+// html unchanged if it cannot be parsed.
+func Strip(html string) string {
+	return editContainer(html, removeBoilerplate)
+}
+
+// Parses a lyrics container once, applies each edit in order and renders the result.
+// Returns the given html unchanged if it cannot be parsed.
+func editContainer(html string, edits ...func(*goquery.Document)) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		slog.Warn("Failed to parse lyrics html", "error", err)
+		return html
+	}
+
+	for _, edit := range edits {
+		edit(doc)
+	}
+
+	rendered, err := doc.Find("body").Html()
+	if err != nil {
+		slog.Warn("Failed to render lyrics html", "error", err)
+		return html
+	}
+	return rendered
+}
+
+func removeBoilerplate(doc *goquery.Document) {
+	for _, selector := range boilerplateSelectors {
+		doc.Find(selector).Remove()
+	}
+}
+
+// Removes the embed counter from the last non-blank line, so lyrics that merely end
+// in "Embed" elsewhere are kept
+func trimEmbedCounter(lines []string) {
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.TrimSpace(lines[i]) == "" {
+			continue
+		}
+		lines[i] = embedSuffix.ReplaceAllString(strings.TrimRight(lines[i], " "), "")
+		return
+	}
+}
+
+func isBoilerplateLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	for _, re := range boilerplateLines {
+		if re.MatchString(trimmed) {
+			return true
+		}
+	}
+	return false
+}
+
+// Artists credited in a section header, e.g. "[Verse 1: Foo & Bar]"
+func sectionArtists(line string) []string {
+	_, credit, _ := strings.Cut(line, ":")
+	credit, _, _ = strings.Cut(credit, "]")
+	return names.Split(html.UnescapeString(credit))
+}
+
+// Section header without brackets, e.g. "Verse 1: Foo & Bar"
+func sectionHeader(line string) string {
+	header := strings.TrimSpace(html.UnescapeString(line))
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(header, "["), "]"))
 }
 
 func isMetaLine(line string) bool {
@@ -1,14 +1,20 @@
 package scraper
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/jseashell/lyrics-db-seeder/internal/genius"
+	"github.com/jseashell/lyrics-db-seeder/internal/names"
 )
 
 func Test_Parse_AnchorLink(t *testing.T) {
-	artistName := "foo"
+	artistName := names.New("foo")
 	song := genius.SongWithExtras{
 		Album: &genius.Album{
 			Name: "bar",
@@ -23,7 +29,7 @@ func Test_Parse_AnchorLink(t *testing.T) {
 }
 
 func Test_Parse_LineBreak(t *testing.T) {
-	artistName := "foo"
+	artistName := names.New("foo")
 	song := genius.SongWithExtras{
 		Album: &genius.Album{
 			Name: "bar",
@@ -39,7 +45,7 @@ func Test_Parse_LineBreak(t *testing.T) {
 }
 
 func Test_Parse_SkipFeaturePart(t *testing.T) {
-	artistName := "foo"
+	artistName := names.New("foo")
 	featureArtist := "bar"
 	song := genius.SongWithExtras{
 		Album: &genius.Album{
@@ -50,7 +56,7 @@ func Test_Parse_SkipFeaturePart(t *testing.T) {
 	toSkip := "feature words"
 	toParse := "artist lyric"
 
-	html := "[Verse: " + featureArtist + "]<br/>" + toSkip + "<br/>[Chorus: " + artistName + "]<br/>" + toParse
+	html := "[Verse: " + featureArtist + "]<br/>" + toSkip + "<br/>[Chorus: " + artistName.Name() + "]<br/>" + toParse
 	want := []string{toParse}
 	got := Parse(artistName, song, html)
 
@@ -58,3 +64,209 @@ func Test_Parse_SkipFeaturePart(t *testing.T) {
 		t.Fatalf("want %v got %v", want, got)
 	}
 }
+
+func Test_Parse_SkipPlaceholder(t *testing.T) {
+	artistName := names.New("foo")
+	song := genius.SongWithExtras{}
+	html := "Lyrics for this song have yet to be released. Please check back once the song has been released."
+	got := Parse(artistName, song, html)
+
+	if len(got) != 0 {
+		t.Fatalf("want no lyrics got %v", got)
+	}
+}
+
+func Test_Status(t *testing.T) {
+	tests := []struct {
+		name        string
+		song        genius.SongWithExtras
+		placeholder string
+		lyrics      []string
+		want        LyricStatus
+	}{
+		{"complete", genius.SongWithExtras{}, "", []string{"foo"}, Complete},
+		{"instrumental flag", genius.SongWithExtras{Song: genius.Song{Instrumental: true}}, "", nil, Instrumental},
+		{"instrumental placeholder", genius.SongWithExtras{}, "This song is an instrumental", nil, Instrumental},
+		{"unreleased placeholder", genius.SongWithExtras{}, "Lyrics for this song have yet to be released.", nil, Unreleased},
+		{"unreleased state", genius.SongWithExtras{Song: genius.Song{LyricsState: "unreleased"}}, "", nil, Unreleased},
+		{"missing", genius.SongWithExtras{}, "", nil, Missing},
+	}
+
+	for _, tt := range tests {
+		got := Status(tt.song, tt.placeholder, tt.lyrics)
+		if tt.want != got {
+			t.Errorf("%s: want %q got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+// Regression corpus of Genius.com lyrics container snippets, trimmed to the markup
+// around each kind of boilerplate. Each testdata/*.html snippet is parsed and compared
+// to the lyrics in the matching *.txt file.
+func Test_Parse_Boilerplate(t *testing.T) {
+	artistName := names.New("John Newton")
+	song := genius.SongWithExtras{}
+
+	files, err := filepath.Glob(filepath.Join("testdata", "*.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no testdata")
+	}
+
+	for _, file := range files {
+		html, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		txt, err := os.ReadFile(strings.TrimSuffix(file, ".html") + ".txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := strings.Split(strings.TrimSpace(string(txt)), "\n")
+		got := Parse(artistName, song, strings.TrimSpace(string(html)))
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("%s: want %q got %q", file, want, got)
+		}
+	}
+}
+
+func Test_Page_Parse_EmbedSuffix(t *testing.T) {
+	artistName := names.New("foo")
+	page := Page{Containers: []string{
+		"first line ends in Embed<br/>",
+		"middle line ends in Embed<br/>last line42Embed",
+	}}
+	want := []string{"first line ends in Embed", "middle line ends in Embed", "last line"}
+	got, _, _ := page.Parse(artistName, genius.SongWithExtras{})
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want %q got %q", want, got)
+	}
+}
+
+func Test_ParseWithReferents(t *testing.T) {
+	artistName := names.New("foo")
+	song := genius.SongWithExtras{}
+	html := "[Verse: foo]<br/>plain <a href=\"/123/Foo-bar-lyrics/Annotated\" class=\"ReferentFragmentdesktop__ClickTarget\"><span>annotated it&#39;s</span></a> tail<br/>" +
+		"<a href=\"https://genius.com/456/Foo-bar-lyrics/Spanning\"><span>first half<br/>second half</span></a><br/>" +
+		"<a href=\"https://example.com\">not a referent</a>"
+
+	wantLyrics := []string{"plain annotated it's tail", "first half", "second half", "not a referent"}
+	wantReferents := []Referent{
+		{ID: 123, Line: 0, Start: 6, End: 20},
+		{ID: 456, Line: 1, Start: 0, End: 10},
+		{ID: 456, Line: 2, Start: 0, End: 11},
+	}
+	gotLyrics, gotReferents := ParseWithReferents(artistName, song, html)
+
+	if !reflect.DeepEqual(wantLyrics, gotLyrics) {
+		t.Fatalf("want %q got %q", wantLyrics, gotLyrics)
+	}
+	if !reflect.DeepEqual(wantReferents, gotReferents) {
+		t.Fatalf("want %v got %v", wantReferents, gotReferents)
+	}
+}
+
+func Test_ParseWithReferents_Trimmed(t *testing.T) {
+	artistName := names.New("foo")
+	song := genius.SongWithExtras{}
+	html := " [<a href=\"/789/Foo-bar-lyrics/Bracketed\">bracketed</a>]"
+
+	wantLyrics := []string{"bracketed"}
+	wantReferents := []Referent{{ID: 789, Line: 0, Start: 0, End: 9}}
+	gotLyrics, gotReferents := ParseWithReferents(artistName, song, html)
+
+	if !reflect.DeepEqual(wantLyrics, gotLyrics) {
+		t.Fatalf("want %q got %q", wantLyrics, gotLyrics)
+	}
+	if !reflect.DeepEqual(wantReferents, gotReferents) {
+		t.Fatalf("want %v got %v", wantReferents, gotReferents)
+	}
+}
+
+func Test_Parse_YouMightAlsoLike(t *testing.T) {
+	artistName := names.New("foo")
+	song := genius.SongWithExtras{}
+	html := "You might also like<br/>You might also like me if I stayed"
+	want := []string{"You might also like me if I stayed"}
+	got := Parse(artistName, song, html)
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want %q got %q", want, got)
+	}
+}
+
+func Test_Parse_AliasPart(t *testing.T) {
+	artistName := names.New("foo", "qux")
+	song := genius.SongWithExtras{}
+	html := "[Verse 1: bar]<br/>skipped<br/>[Verse 2: Qux &amp; bar]<br/>kept"
+	want := []string{"kept"}
+	got := Parse(artistName, song, html)
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want %v got %v", want, got)
+	}
+}
+
+func Test_Page_Sections(t *testing.T) {
+	artistName := names.New("foo")
+	page := Page{Containers: []string{
+		"intro line<br/>[Verse 1: bar]<br/>skipped<br/>[Chorus]<br/>also skipped",
+		"[Verse 2: Foo &amp; bar]<br/>kept",
+	}}
+
+	want := []Section{
+		{Header: "", Artists: []string{}, Kept: true, Lines: []string{"intro line"}},
+		{Header: "Verse 1: bar", Artists: []string{"bar"}, Kept: false, Lines: []string{"skipped"}},
+		{Header: "Chorus", Artists: []string{"bar"}, Kept: false, Lines: []string{"also skipped"}},
+		{Header: "Verse 2: Foo & bar", Artists: []string{"Foo", "bar"}, Kept: true, Lines: []string{"kept"}},
+	}
+	got := page.Sections(artistName)
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want %+v got %+v", want, got)
+	}
+
+	lyrics, _, status := page.Parse(artistName, genius.SongWithExtras{})
+	if !reflect.DeepEqual([]string{"intro line", "kept"}, lyrics) || status != Complete {
+		t.Fatalf("got %q %q", lyrics, status)
+	}
+}
+
+func Test_songIDFromHTML(t *testing.T) {
+	tests := []struct {
+		html   string
+		want   int
+		wantOk bool
+	}{
+		{`<meta content="genius://songs/4349437" name="twitter:app:url:iphone" />`, 4349437, true},
+		{`<meta name="newrelic-resource-path" content="/songs/378195" />`, 378195, true},
+		{`<meta content="genius://artists/1" />`, 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := songIDFromHTML(tt.html)
+		if got != tt.want || ok != tt.wantOk {
+			t.Errorf("%s: want %d %v got %d %v", tt.html, tt.want, tt.wantOk, got, ok)
+		}
+	}
+}
+
+func Test_SongID_Path(t *testing.T) {
+	got, err := SongID(context.Background(), "https://genius.com/songs/378195")
+	if err != nil || got != 378195 {
+		t.Fatalf("got %d, %v", got, err)
+	}
+}
+
+func Test_SongID_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := SongID(ctx, "https://genius.com/Young-thug-best-friend-lyrics")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("want %v got %v", context.Canceled, err)
+	}
+}
@@ -6,12 +6,14 @@
 package search
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
-	"strings"
+	"sort"
 
 	"github.com/jseashell/lyrics-db-seeder/internal/genius"
+	"github.com/jseashell/lyrics-db-seeder/internal/names"
 	"golang.org/x/exp/maps"
 )
 
@@ -35,15 +37,60 @@ type SearchResponseArtist struct {
 	Name string `json:"name"`
 }
 
-func Query(artistName string, affiliations []string, includeFeatured bool, includeAnded bool) ([]int, error) {
-	artistMap := make(map[int]interface{})
+// Search settings
+type Options struct {
+	// Other names the artist performs under, matched as the artist
+	Aliases []string
+	// Affiliated artists to search alongside the artist
+	Affiliations []string
+	// Searches for songs featuring the artist on an affiliation's song
+	IncludeFeatured bool
+	// Searches for songs credited to the artist "and" an affiliation
+	IncludeAnded bool
+	// Artist IDs never to return
+	ExcludeIDs []int
+	// Artist names never to return
+	ExcludeNames []string
+	// How the artist name is matched, defaults to [names.Substring]
+	MatchMode names.Mode
+}
+
+// An artist ID found by searching, with every reason it matched
+type Candidate struct {
+	// Genius.com primary artist ID of the matching search results
+	ID int
+	// Genius.com primary artist name of the matching search results
+	Name string
+	// Every search result that matched
+	Matches []Match
+	// Reason the candidate is excluded, empty when included
+	Excluded string
+}
+
+// A search result that matched the artist
+type Match struct {
+	// Search term that yielded the result
+	SearchTerm string
+	// All artists on the matching song
+	ArtistNames string
+	// Why the result matched, e.g. "primary artist"
+	Reason string
+}
 
-	primaryArtistMap := search(artistName, artistName)
-	maps.Copy(artistMap, primaryArtistMap)
+// Searches for the artist and all their affiliations. Returns the included candidate artist IDs.
+func Query(ctx context.Context, artistName string, opts Options) ([]int, error) {
+	candidates := Candidates(ctx, artistName, opts)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-	for _, affiliation := range affiliations {
-		affiliationMap := searchWithAffiliation(artistName, affiliation, includeFeatured, includeFeatured)
-		maps.Copy(artistMap, affiliationMap)
+	artistMap := make(map[int]interface{})
+	for _, candidate := range candidates {
+		if candidate.Excluded == "" {
+			artistMap[candidate.ID] = candidate.Name
+		} else {
+			slog.Info("Excluded", slog.Int("artist_id", candidate.ID), "name", candidate.Name, "reason", candidate.Excluded)
+		}
 	}
 
 	artistIds := maps.Keys(artistMap)
@@ -55,70 +102,125 @@ func Query(artistName string, affiliations []string, includeFeatured bool, inclu
 	}
 }
 
-// Runs a [search] for an affiliated contributor, e.g. "Other Artist (Ft. My Artist)"
-func searchWithAffiliation(artistName, affiliation string, includeFeatured bool, includeAnded bool) map[int]interface{} {
-	ret := make(map[int]interface{})
-
-	affiliationMap := search(artistName, affiliation)
-	maps.Copy(ret, affiliationMap)
-
-	if includeAnded {
-		artistAndAffiliationMap := search(artistName, fmt.Sprintf("%s and %s", artistName, affiliation))
-		maps.Copy(ret, artistAndAffiliationMap)
-
-		artistAndSymAffiliationMap := search(artistName, fmt.Sprintf("%s & %s", artistName, affiliation))
-		maps.Copy(ret, artistAndSymAffiliationMap)
-
-		affiliationAndArtistMap := search(artistName, fmt.Sprintf("%s and %s", affiliation, artistName))
-		maps.Copy(ret, affiliationAndArtistMap)
+// Searches for the artist and all their affiliations. Returns every candidate artist,
+// including excluded candidates, ordered by artist ID. Stops searching when the context
+// is canceled.
+func Candidates(ctx context.Context, artistName string, opts Options) []Candidate {
+	candidates := make(map[int]*Candidate)
+	m := names.New(artistName, opts.Aliases...).WithMode(opts.MatchMode)
 
-		affiliationAndSymArtistMap := search(artistName, fmt.Sprintf("%s & %s", affiliation, artistName))
-		maps.Copy(ret, affiliationAndSymArtistMap)
+	search(ctx, candidates, m, artistName)
+	for _, alias := range opts.Aliases {
+		search(ctx, candidates, m, alias)
 	}
 
-	if includeFeatured {
-		ftMap := search(artistName, fmt.Sprintf("%s (Ft. %s)", affiliation, artistName))
-		maps.Copy(ret, ftMap)
-
-		ft2Map := search(artistName, fmt.Sprintf("%s (ft. %s)", affiliation, artistName))
-		maps.Copy(ret, ft2Map)
+	for _, affiliation := range opts.Affiliations {
+		if affiliation == "" {
+			continue
+		}
+		searchWithAffiliation(ctx, candidates, m, artistName, affiliation, opts)
+	}
 
-		featMap := search(artistName, fmt.Sprintf("%s (feat. %s)", affiliation, artistName))
-		maps.Copy(ret, featMap)
+	ret := []Candidate{}
+	for _, candidate := range candidates {
+		candidate.Excluded = excluded(*candidate, opts)
+		ret = append(ret, *candidate)
 	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].ID < ret[j].ID
+	})
 
 	return ret
 }
 
-// Searches Genius.com for the given search string. Attempts to match results to the given artist name
-func search(artistName string, search string) map[int]interface{} {
-	searchResponse := genius.Search(search)
+// Runs a [search] for an affiliated contributor, e.g. "Other Artist (Ft. My Artist)"
+func searchWithAffiliation(ctx context.Context, candidates map[int]*Candidate, m names.Matcher, artistName, affiliation string, opts Options) {
+	search(ctx, candidates, m, affiliation)
+
+	if opts.IncludeAnded {
+		search(ctx, candidates, m, fmt.Sprintf("%s and %s", artistName, affiliation))
+		search(ctx, candidates, m, fmt.Sprintf("%s & %s", artistName, affiliation))
+		search(ctx, candidates, m, fmt.Sprintf("%s and %s", affiliation, artistName))
+		search(ctx, candidates, m, fmt.Sprintf("%s & %s", affiliation, artistName))
+	}
+
+	if opts.IncludeFeatured {
+		search(ctx, candidates, m, fmt.Sprintf("%s (Ft. %s)", affiliation, artistName))
+		search(ctx, candidates, m, fmt.Sprintf("%s (ft. %s)", affiliation, artistName))
+		search(ctx, candidates, m, fmt.Sprintf("%s (feat. %s)", affiliation, artistName))
+	}
+}
 
-	artistIdMap := make(map[int]interface{})
+// Searches Genius.com for the given search string. Attempts to match results to the given
+// artist and records each match in candidates, keyed by primary artist ID.
+func search(ctx context.Context, candidates map[int]*Candidate, m names.Matcher, search string) {
+	if ctx.Err() != nil {
+		return
+	}
+	searchResponse := genius.Search(ctx, search)
 
 	for _, hit := range searchResponse.Response.Hits {
-		isInArtistNames := strings.Contains(hit.Result.ArtistNames, artistName)
-		isPrimaryArtist := strings.Contains(hit.Result.PrimaryArtist.Name, artistName)
-		isFeaturedArtist := isFeaturedArtist(hit.Result.FeaturedArtists, artistName)
-
 		artistId := hit.Result.PrimaryArtist.ID
-		if isInArtistNames || isPrimaryArtist || isFeaturedArtist {
-			slog.Info("Match", "artist_names", hit.Result.ArtistNames, slog.Int("artist_id", artistId))
-			artistIdMap[artistId] = hit.Result.PrimaryArtist.Name
+		reason := matchReason(hit, m)
+		if reason != "" {
+			slog.Info("Match", "artist_names", hit.Result.ArtistNames, slog.Int("artist_id", artistId), "reason", reason)
+
+			candidate, ok := candidates[artistId]
+			if !ok {
+				candidate = &Candidate{ID: artistId, Name: hit.Result.PrimaryArtist.Name}
+				candidates[artistId] = candidate
+			}
+			candidate.Matches = append(candidate.Matches, Match{
+				SearchTerm:  search,
+				ArtistNames: hit.Result.ArtistNames,
+				Reason:      reason,
+			})
 		} else {
 			slog.Debug("No match", "searching", fmt.Sprintf("%s (%d)", search, artistId), "artist_names", hit.Result.ArtistNames, "primary_artist", hit.Result.PrimaryArtist.Name, "featured_artists", hit.Result.FeaturedArtists)
 		}
 	}
+}
+
+// Explains why the search hit matches the artist, or empty string if it does not
+func matchReason(hit genius.SearchHit, m names.Matcher) string {
+	if m.Matches(hit.Result.PrimaryArtist.Name) {
+		return "primary artist"
+	}
+
+	if isFeaturedArtist(hit.Result.FeaturedArtists, m) {
+		return "featured artist"
+	}
 
-	return artistIdMap
+	if m.MatchesAny(names.Split(hit.Result.ArtistNames)) {
+		return "artist names"
+	}
+
+	return ""
 }
 
-func isFeaturedArtist(featuredArtists []genius.Artist, artistName string) bool {
+func isFeaturedArtist(featuredArtists []genius.Artist, m names.Matcher) bool {
 	for _, featuredArtist := range featuredArtists {
-		if featuredArtist.Name == artistName {
+		if m.Matches(featuredArtist.Name) {
 			return true
 		}
 	}
 
 	return false
 }
+
+// Explains why the candidate is excluded, or empty string if it is not
+func excluded(candidate Candidate, opts Options) string {
+	for _, id := range opts.ExcludeIDs {
+		if candidate.ID == id {
+			return fmt.Sprintf("artist ID %d is excluded", id)
+		}
+	}
+
+	for _, name := range opts.ExcludeNames {
+		if names.Normalize(candidate.Name) == names.Normalize(name) {
+			return fmt.Sprintf("artist name %q is excluded", name)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,63 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/jseashell/lyrics-db-seeder/internal/genius"
+	"github.com/jseashell/lyrics-db-seeder/internal/names"
+)
+
+func hit(primary string, featured []string, artistNames string) genius.SearchHit {
+	var h genius.SearchHit
+	h.Result.PrimaryArtist = genius.Artist{Name: primary}
+	for _, name := range featured {
+		h.Result.FeaturedArtists = append(h.Result.FeaturedArtists, genius.Artist{Name: name})
+	}
+	h.Result.ArtistNames = artistNames
+	return h
+}
+
+func Test_MatchReason(t *testing.T) {
+	tests := []struct {
+		name string
+		hit  genius.SearchHit
+		mode names.Mode
+		want string
+	}{
+		{"substring primary", hit("Future Islands", nil, "Future Islands"), names.Substring, "primary artist"},
+		{"word still matches longer name", hit("Future Islands", nil, "Future Islands"), names.Word, "primary artist"},
+		{"word rejects adjoined", hit("Futuristic", nil, "Futuristic"), names.Word, ""},
+		{"exact rejects longer name", hit("Future Islands", nil, "Future Islands"), names.Exact, ""},
+		{"exact featured", hit("Drake", []string{"Future"}, "Drake (Ft. Future)"), names.Exact, "featured artist"},
+		{"exact artist names", hit("Drake", nil, "Drake & Future"), names.Exact, "artist names"},
+		{"no match", hit("Drake", nil, "Drake"), names.Substring, ""},
+	}
+
+	for _, tt := range tests {
+		got := matchReason(tt.hit, names.New("Future").WithMode(tt.mode))
+		if tt.want != got {
+			t.Errorf("%s: want %q got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func Test_MatchReason_Normalized(t *testing.T) {
+	m := names.New("Beyoncé").WithMode(names.Exact)
+	if got := matchReason(hit("Beyonce", nil, "Beyonce"), m); got != "primary artist" {
+		t.Fatalf("want %q got %q", "primary artist", got)
+	}
+}
+
+func Test_Excluded(t *testing.T) {
+	opts := Options{ExcludeIDs: []int{1}, ExcludeNames: []string{"Future Islands"}}
+
+	if got := excluded(Candidate{ID: 1, Name: "Foo"}, opts); got == "" {
+		t.Error("want excluded by ID")
+	}
+	if got := excluded(Candidate{ID: 2, Name: "FUTURE ISLANDS"}, opts); got == "" {
+		t.Error("want excluded by name")
+	}
+	if got := excluded(Candidate{ID: 3, Name: "Future"}, opts); got != "" {
+		t.Errorf("want included got %q", got)
+	}
+}